@@ -231,3 +231,20 @@ func DownloadAssetAsync(asset Downloadable, out io.Writer, c AsyncDownloadChanne
 		c <- results
 	}()
 }
+
+// progressWriter is an io.Writer reporting the number of bytes written to
+// the wrapped writer.
+type progressWriter struct {
+	w        io.Writer
+	written  int64
+	total    int64
+	progress func(written, total int64)
+}
+
+// Write writes p to the wrapped writer and reports the progress.
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	pw.written += int64(n)
+	pw.progress(pw.written, pw.total)
+	return n, err
+}
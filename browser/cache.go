@@ -0,0 +1,111 @@
+package browser
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cacheControl returns the directives of the Cache-Control header in the given
+// headers, keyed by the lower case directive name.
+func cacheControl(h http.Header) map[string]string {
+	directives := make(map[string]string)
+	for _, line := range h.Values("Cache-Control") {
+		for _, part := range strings.Split(line, ",") {
+			name, value := strings.TrimSpace(part), ""
+			if i := strings.Index(name, "="); i >= 0 {
+				name, value = strings.TrimSpace(name[:i]), strings.Trim(strings.TrimSpace(name[i+1:]), `"`)
+			}
+			if name != "" {
+				directives[strings.ToLower(name)] = value
+			}
+		}
+	}
+	return directives
+}
+
+// cacheFresh returns whether a response stored with the given headers may be
+// used without asking the server, at the given time.
+func cacheFresh(h http.Header, now time.Time) bool {
+	cc := cacheControl(h)
+	if _, ok := cc["no-cache"]; ok {
+		return false
+	}
+	date, err := http.ParseTime(h.Get("Date"))
+	if err != nil {
+		return false
+	}
+	if maxAge, ok := cc["max-age"]; ok {
+		secs, err := strconv.Atoi(maxAge)
+		return err == nil && now.Sub(date) < time.Duration(secs)*time.Second
+	}
+	if expires, err := http.ParseTime(h.Get("Expires")); err == nil {
+		return now.Before(expires)
+	}
+	return false
+}
+
+// cacheStorable returns whether the response to the given request may be
+// stored in the cache.
+func cacheStorable(req *http.Request, resp *http.Response) bool {
+	if req.Method != "GET" || resp.StatusCode != http.StatusOK {
+		return false
+	}
+	if _, ok := cacheControl(req.Header)["no-store"]; ok {
+		return false
+	}
+	cc := cacheControl(resp.Header)
+	if _, ok := cc["no-store"]; ok {
+		return false
+	}
+	_, maxAge := cc["max-age"]
+	return maxAge || resp.Header.Get("ETag") != "" ||
+		resp.Header.Get("Last-Modified") != "" || resp.Header.Get("Expires") != ""
+}
+
+// cacheBypassed returns whether the given request asks not to be answered
+// from the cache, with a no-cache or no-store Cache-Control directive.
+func cacheBypassed(req *http.Request) bool {
+	cc := cacheControl(req.Header)
+	_, noCache := cc["no-cache"]
+	_, noStore := cc["no-store"]
+	return noCache || noStore
+}
+
+// cachedResponse returns a response for the given request using a body and
+// headers read from the cache.
+func cachedResponse(req *http.Request, body []byte, h http.Header) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// readCacheBody reads the body of the given response so it can be stored in
+// the cache, and replaces the body with the bytes read.
+func readCacheBody(resp *http.Response) ([]byte, error) {
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// drainBody reads and closes the body of a response which is not used.
+func drainBody(resp *http.Response) {
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+}
@@ -0,0 +1,220 @@
+package browser
+
+import (
+	"github.com/headzoo/surf/errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// savedCookie is the representation of a cookie used by SaveCookies and
+// LoadCookies.
+type savedCookie struct {
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain"`
+	Path     string    `json:"path"`
+	Expires  time.Time `json:"expires"`
+	Secure   bool      `json:"secure"`
+	HttpOnly bool      `json:"http_only"`
+	HostOnly bool      `json:"host_only"`
+}
+
+// key returns the value identifying the cookie in the jar.
+func (c *savedCookie) key() string {
+	return c.Domain + ";" + c.Path + ";" + c.Name
+}
+
+// expired returns whether the cookie has expired at the given time.
+// Session cookies never expire.
+func (c *savedCookie) expired(now time.Time) bool {
+	return !c.Expires.IsZero() && !c.Expires.After(now)
+}
+
+// cookie returns the *http.Cookie type and the URL used to add the cookie to
+// a jar.
+func (c *savedCookie) cookie() (*url.URL, *http.Cookie) {
+	u := &url.URL{Scheme: "http", Host: c.Domain, Path: c.Path}
+	if c.Secure {
+		u.Scheme = "https"
+	}
+	cookie := &http.Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Path:     c.Path,
+		Expires:  c.Expires,
+		Secure:   c.Secure,
+		HttpOnly: c.HttpOnly,
+	}
+	if !c.HostOnly {
+		cookie.Domain = c.Domain
+	}
+	return u, cookie
+}
+
+// netscapeHttpOnly is the prefix of the domain of http-only cookies in the
+// Netscape cookies.txt format.
+const netscapeHttpOnly = "#HttpOnly_"
+
+// netscape returns the line representing the cookie in the Netscape
+// cookies.txt format, without the line ending.
+func (c *savedCookie) netscape() string {
+	domain, subdomains := c.Domain, "FALSE"
+	if !c.HostOnly {
+		domain, subdomains = "."+domain, "TRUE"
+	}
+	if c.HttpOnly {
+		domain = netscapeHttpOnly + domain
+	}
+	secure := "FALSE"
+	if c.Secure {
+		secure = "TRUE"
+	}
+	expires := int64(0)
+	if !c.Expires.IsZero() {
+		expires = c.Expires.Unix()
+	}
+	return strings.Join([]string{
+		domain, subdomains, c.Path, secure, strconv.FormatInt(expires, 10), c.Name, c.Value,
+	}, "\t")
+}
+
+// parseNetscapeCookie parses a line of a Netscape cookies.txt file.
+//
+// Returns a nil cookie for blank lines and comments.
+func parseNetscapeCookie(line string) (*savedCookie, error) {
+	line = strings.TrimRight(line, "\r\n")
+	httpOnly := strings.HasPrefix(line, netscapeHttpOnly)
+	if httpOnly {
+		line = line[len(netscapeHttpOnly):]
+	}
+	if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+		return nil, nil
+	}
+	fields := strings.Split(line, "\t")
+	if len(fields) != 7 {
+		return nil, errors.New("Invalid cookies.txt line '%s': expected 7 fields, found %d.", line, len(fields))
+	}
+	expires, err := strconv.ParseInt(fields[4], 10, 64)
+	if err != nil {
+		return nil, errors.New("Invalid cookies.txt line '%s': invalid expiry '%s'.", line, fields[4])
+	}
+	c := &savedCookie{
+		Name:     fields[5],
+		Value:    fields[6],
+		Domain:   strings.ToLower(strings.TrimPrefix(fields[0], ".")),
+		Path:     fields[2],
+		Secure:   strings.EqualFold(fields[3], "TRUE"),
+		HttpOnly: httpOnly,
+		HostOnly: !strings.EqualFold(fields[1], "TRUE"),
+	}
+	if expires > 0 {
+		c.Expires = time.Unix(expires, 0)
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	return c, nil
+}
+
+// cookieRecorder is an http.CookieJar passing cookies to the browser cookie
+// jar, and recording them so they can be saved.
+//
+// The http.CookieJar interface has no way to list the cookies in a jar, so the
+// browser keeps its own record of the cookies it adds.
+type cookieRecorder struct {
+	bow *Browser
+}
+
+// SetCookies adds the cookies to the browser cookie jar, and records the
+// cookies accepted by the jar.
+func (cr *cookieRecorder) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	cr.bow.cookies.SetCookies(u, cookies)
+	cr.bow.recordCookies(u, cookies)
+}
+
+// Cookies returns the cookies to send in a request for the given URL.
+func (cr *cookieRecorder) Cookies(u *url.URL) []*http.Cookie {
+	return cr.bow.cookies.Cookies(u)
+}
+
+// recordCookies records the cookies set by a response for the given URL,
+// after they have been added to the cookie jar.
+//
+// Cookies rejected by the jar, such as cookies for another domain or for a
+// public suffix, are not recorded, so they are never saved.
+func (bow *Browser) recordCookies(u *url.URL, cookies []*http.Cookie) {
+	if bow.cookieLog == nil {
+		bow.cookieLog = make(map[string]*savedCookie)
+	}
+	now := time.Now()
+	for _, cookie := range cookies {
+		c := &savedCookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Domain:   strings.TrimPrefix(strings.ToLower(cookie.Domain), "."),
+			Path:     cookie.Path,
+			Expires:  cookie.Expires,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+		}
+		if c.Domain == "" {
+			c.Domain = cookieHost(u)
+			c.HostOnly = true
+		}
+		if c.Path == "" || c.Path[0] != '/' {
+			c.Path = cookieDefaultPath(u.Path)
+		}
+		if cookie.MaxAge > 0 {
+			c.Expires = now.Add(time.Duration(cookie.MaxAge) * time.Second)
+		}
+		if cookie.MaxAge < 0 || c.expired(now) {
+			delete(bow.cookieLog, c.key())
+			continue
+		}
+		if bow.cookieAccepted(u, c) {
+			bow.cookieLog[c.key()] = c
+		}
+	}
+}
+
+// cookieAccepted returns whether the cookie jar holds the given cookie, which
+// was set by a response for the given URL.
+//
+// The jar is asked for the cookies sent to the path of the cookie on the host
+// of the URL, which include the cookie unless the jar rejected it.
+func (bow *Browser) cookieAccepted(u *url.URL, c *savedCookie) bool {
+	check := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: c.Path}
+	if c.Secure {
+		check.Scheme = "https"
+	}
+	for _, cookie := range bow.cookies.Cookies(check) {
+		if cookie.Name == c.Name && cookie.Value == c.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// cookieHost returns the host of the given URL, without the port, as used
+// for the domain of host-only cookies.
+func cookieHost(u *url.URL) string {
+	host := u.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
+
+// cookieDefaultPath returns the default path of cookies set by a response for
+// the given request path, as defined by RFC 6265 section 5.1.4.
+func cookieDefaultPath(path string) string {
+	i := strings.LastIndex(path, "/")
+	if path == "" || path[0] != '/' || i <= 0 {
+		return "/"
+	}
+	return path[:i]
+}
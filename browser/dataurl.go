@@ -0,0 +1,70 @@
+package browser
+
+import (
+	"encoding/base64"
+	"github.com/headzoo/surf/errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// dataResponse returns a response holding the data of the data: URL of the
+// given request, as defined by RFC 2397.
+//
+// The media type of the URL is used for the Content-Type header, and
+// defaults to "text/plain;charset=US-ASCII". The fragment of the URL is not
+// part of the data, so a "#" in the data must be escaped as "%23".
+func dataResponse(req *http.Request) (*http.Response, error) {
+	raw := req.URL.Opaque
+	if req.URL.RawQuery != "" || req.URL.ForceQuery {
+		// The data may contain a question mark, which is parsed as the start
+		// of a query.
+		raw += "?" + req.URL.RawQuery
+	}
+	i := strings.Index(raw, ",")
+	if i < 0 {
+		return nil, errors.New("Invalid data URL '%s': missing comma.", req.URL.String())
+	}
+	mediaType, data := raw[:i], raw[i+1:]
+
+	base64Data := false
+	if strings.HasSuffix(strings.ToLower(mediaType), ";base64") {
+		base64Data = true
+		mediaType = mediaType[:len(mediaType)-len(";base64")]
+	}
+	mediaType, err := url.PathUnescape(mediaType)
+	if err != nil {
+		return nil, errors.New("Invalid data URL '%s': %s", req.URL.String(), err)
+	}
+	if mediaType == "" || strings.HasPrefix(mediaType, ";") {
+		mediaType = "text/plain" + mediaType
+		if !strings.Contains(strings.ToLower(mediaType), "charset=") {
+			mediaType += ";charset=US-ASCII"
+		}
+	}
+
+	data, err = url.PathUnescape(data)
+	if err != nil {
+		return nil, errors.New("Invalid data URL '%s': %s", req.URL.String(), err)
+	}
+	body := []byte(data)
+	if base64Data {
+		data = strings.Map(func(r rune) rune {
+			if r == ' ' || r == '\t' || r == '\n' || r == '\r' {
+				return -1
+			}
+			return r
+		}, data)
+		body, err = base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			body, err = base64.RawStdEncoding.DecodeString(data)
+		}
+		if err != nil {
+			return nil, errors.New("Invalid data URL '%s': %s", req.URL.String(), err)
+		}
+	}
+
+	h := make(http.Header)
+	h.Set("Content-Type", mediaType)
+	return cachedResponse(req, body, h), nil
+}
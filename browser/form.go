@@ -1,48 +1,125 @@
 package browser
 
 import (
+	"bytes"
+	"context"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/headzoo/surf/errors"
+	"github.com/headzoo/surf/event"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"io"
+	"mime/multipart"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 // Submittable represents an element that may be submitted, such as a form.
 type Submittable interface {
+	ID() string
+	Name() string
 	Method() string
 	Action() string
+	Enctype() string
 	Input(name, value string) error
+	Set(name, value string)
+	AddValue(name, value string)
+	SetValueAt(name string, index int, value string) error
+	RemoveField(name string) error
+	Enable(name string) error
+	Disable(name string) error
+	Check(name string) error
+	CheckValue(name, value string) error
+	Uncheck(name string) error
+	UncheckValue(name, value string) error
+	SelectOption(name, value string) error
+	Pick(name, value string) error
+	File(name, filename string, data io.Reader) error
+	Values() url.Values
+	Buttons() url.Values
+	HasButtons() bool
+	Reset()
+	Validate() error
+	SetValidation(enabled bool)
+	SetPercentEncodeSpaces(enabled bool)
 	Click(button string) error
+	ClickWithContext(ctx context.Context, button string) error
+	ClickByValue(name, value string) error
+	ClickByValueWithContext(ctx context.Context, name, value string) error
+	ClickImage(name string, x, y int) error
+	ClickImageWithContext(ctx context.Context, name string, x, y int) error
 	Submit() error
+	SubmitWithContext(ctx context.Context) error
+	Field(name string) (*goquery.Selection, bool)
 	Dom() *goquery.Selection
+	HTML() (string, error)
 }
 
+// File represents a file uploaded with a form.
+type File struct {
+	// Filename is the name of the file sent to the server.
+	Filename string
+
+	// Data is the file contents.
+	Data io.Reader
+}
+
+// FileSet maps form field names to the files uploaded with them.
+type FileSet map[string]*File
+
 // Form is the default form element.
 type Form struct {
 	bow       Browsable
 	selection *goquery.Selection
 	method    string
 	action    string
+	enctype   string
+	charset   encoding.Encoding
 	fields    url.Values
 	buttons   url.Values
+	order     []string
+	files     FileSet
+	validate  bool
+	toggled   map[string]bool
+	spaces    bool
 }
 
 // NewForm creates and returns a *Form type.
 func NewForm(bow Browsable, s *goquery.Selection) *Form {
-	fields, buttons := serializeForm(s)
-	method, action := formAttributes(bow, s)
-
-	return &Form{
+	method, action, enctype := formAttributes(bow, s)
+	f := &Form{
 		bow:       bow,
 		selection: s,
 		method:    method,
 		action:    action,
-		fields:    fields,
-		buttons:   buttons,
+		enctype:   enctype,
+		charset:   formCharset(s),
 	}
+	f.serialize()
+
+	return f
+}
+
+// ID returns the value of the form id attribute, or an empty string when the
+// form does not have an id.
+func (f *Form) ID() string {
+	id, _ := f.selection.Attr("id")
+	return id
+}
+
+// Name returns the value of the form name attribute, or an empty string when
+// the form does not have a name.
+func (f *Form) Name() string {
+	name, _ := f.selection.Attr("name")
+	return name
 }
 
-// Method returns the form method, eg "GET" or "POST".
+// Method returns the form method, either "GET", "POST", or "DIALOG".
+//
+// Missing and unknown methods are normalized to "GET". Forms using the dialog
+// method cannot be submitted.
 func (f *Form) Method() string {
 	return f.method
 }
@@ -53,7 +130,23 @@ func (f *Form) Action() string {
 	return f.action
 }
 
+// Enctype returns the form encoding type in lower case, eg
+// "application/x-www-form-urlencoded" or "multipart/form-data".
+//
+// The value of the enctype attribute is returned even for forms using the GET
+// method, which are never encoded using the enctype.
+func (f *Form) Enctype() string {
+	return f.enctype
+}
+
 // Input sets the value of a form field.
+//
+// The value replaces every existing value of the field. Use AddValue() to
+// append a value to a multi-valued field, or SetValueAt() to change one of its
+// values, instead.
+//
+// Returns an error when the form does not contain a field with the given name.
+// Use Set() to add fields which are not part of the form.
 func (f *Form) Input(name, value string) error {
 	if _, ok := f.fields[name]; ok {
 		f.fields.Set(name, value)
@@ -63,25 +156,445 @@ func (f *Form) Input(name, value string) error {
 		"No input found with name '%s'.", name)
 }
 
+// Set sets the value of a form field, adding the field when the form does not
+// already contain it.
+//
+// Unlike Input(), Set() never fails, which makes it useful for fields normally
+// added to the form by JavaScript.
+func (f *Form) Set(name, value string) {
+	f.fields.Set(name, value)
+}
+
+// AddValue appends a value to the values of a form field, adding the field
+// when the form does not already contain it.
+//
+// Use AddValue() for fields submitted with several values, such as repeated
+// "items[]" inputs.
+func (f *Form) AddValue(name, value string) {
+	f.fields.Add(name, value)
+}
+
+// SetValueAt sets the value at the given index of the values of a form field,
+// leaving the other values of the field unchanged.
+//
+// The values of a field are in document order, followed by the values added
+// with AddValue(), as returned by Values(). Returns an error when the form
+// does not contain a field with the given name, or when the index is out of
+// range.
+func (f *Form) SetValueAt(name string, index int, value string) error {
+	vals, ok := f.fields[name]
+	if !ok {
+		return errors.NewElementNotFound(
+			"No input found with name '%s'.", name)
+	}
+	if index < 0 || index >= len(vals) {
+		return errors.NewInvalidFormValue(
+			"Index %d is out of range for the %d values of the field '%s'.", index, len(vals), name)
+	}
+	vals[index] = value
+	return nil
+}
+
+// RemoveField removes the field with the given name from the form, so the
+// field is not submitted.
+//
+// Returns an error when the form does not contain a field with the given name.
+func (f *Form) RemoveField(name string) error {
+	if _, ok := f.fields[name]; ok {
+		f.fields.Del(name)
+		return nil
+	}
+	return errors.NewElementNotFound(
+		"No input found with name '%s'.", name)
+}
+
+// Enable enables the controls with the given name, so they are submitted
+// even when the page disabled them.
+//
+// The values of controls which were disabled are read from the form
+// document and added to the form fields. Enabling controls which are already
+// enabled does not change their values.
+//
+// Returns an error when the form does not contain a control with the given
+// name.
+func (f *Form) Enable(name string) error {
+	controls := f.controls(name)
+	if controls.Length() == 0 {
+		return errors.NewElementNotFound(
+			"No input found with name '%s'.", name)
+	}
+	if controls.FilterFunction(func(_ int, s *goquery.Selection) bool {
+		return f.isDisabled(s)
+	}).Length() == 0 {
+		return nil
+	}
+
+	f.toggled[name] = false
+	fields, buttons, order, files := f.parse()
+	f.order = order
+	if vals, ok := fields[name]; ok {
+		f.fields[name] = vals
+	}
+	if vals, ok := buttons[name]; ok {
+		f.buttons[name] = vals
+	}
+	if file, ok := files[name]; ok {
+		f.files[name] = file
+	}
+	return nil
+}
+
+// Disable disables the controls with the given name, so they are not
+// submitted, removing their values from the form fields.
+//
+// Returns an error when the form does not contain a control with the given
+// name.
+func (f *Form) Disable(name string) error {
+	if f.controls(name).Length() == 0 {
+		return errors.NewElementNotFound(
+			"No input found with name '%s'.", name)
+	}
+	f.toggled[name] = true
+	f.fields.Del(name)
+	f.buttons.Del(name)
+	delete(f.files, name)
+	return nil
+}
+
+// Check checks the checkbox with the given name.
+//
+// The value of the checkbox, or "on" when the checkbox has no value, is added
+// to the form fields. The first checkbox is checked when several checkboxes
+// share the name. Use CheckValue() to check one of the others.
+func (f *Form) Check(name string) error {
+	val, err := f.checkboxValue(name, nil)
+	if err != nil {
+		return err
+	}
+	f.check(name, val)
+	return nil
+}
+
+// CheckValue checks the checkbox with the given name and value.
+func (f *Form) CheckValue(name, value string) error {
+	val, err := f.checkboxValue(name, &value)
+	if err != nil {
+		return err
+	}
+	f.check(name, val)
+	return nil
+}
+
+// Uncheck unchecks the checkbox with the given name.
+//
+// The value of the checkbox is removed from the form fields, leaving the
+// values of other checked checkboxes sharing the name. The first checkbox is
+// unchecked when several checkboxes share the name. Use UncheckValue() to
+// uncheck one of the others.
+func (f *Form) Uncheck(name string) error {
+	val, err := f.checkboxValue(name, nil)
+	if err != nil {
+		return err
+	}
+	f.uncheck(name, val)
+	return nil
+}
+
+// UncheckValue unchecks the checkbox with the given name and value.
+func (f *Form) UncheckValue(name, value string) error {
+	val, err := f.checkboxValue(name, &value)
+	if err != nil {
+		return err
+	}
+	f.uncheck(name, val)
+	return nil
+}
+
+// SelectOption selects the option with the given value in the select element
+// with the given name.
+//
+// The value replaces the current selection, or is added to the current selection
+// when the select element allows multiple values.
+func (f *Form) SelectOption(name, value string) error {
+	sel := f.find("select").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		n, _ := s.Attr("name")
+		return n == name && !f.isDisabled(s)
+	}).First()
+	if sel.Length() == 0 {
+		return errors.NewElementNotFound(
+			"No select found with name '%s'.", name)
+	}
+	found := sel.Find("option").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		return optionValue(s) == value
+	})
+	if found.Length() == 0 {
+		return errors.NewInvalidFormValue(
+			"Select '%s' does not contain an option with the value '%s'.", name, value)
+	}
+
+	if _, multiple := sel.Attr("multiple"); !multiple {
+		f.fields.Set(name, value)
+		return nil
+	}
+	for _, v := range f.fields[name] {
+		if v == value {
+			return nil
+		}
+	}
+	f.fields.Add(name, value)
+	return nil
+}
+
+// Pick checks the radio button with the given name and value, unchecking the
+// other radio buttons of the group.
+//
+// Radio groups without a checked radio button are not submitted, as in
+// browsers, so use Pick() to submit a value for them. Radio buttons without a
+// value attribute have the value "on".
+func (f *Form) Pick(name, value string) error {
+	radios := f.find("input").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		n, _ := s.Attr("name")
+		return n == name && controlType(s) == "radio" && !f.isDisabled(s)
+	})
+	if radios.Length() == 0 {
+		return errors.NewElementNotFound(
+			"No radio button found with name '%s'.", name)
+	}
+	found := radios.FilterFunction(func(_ int, s *goquery.Selection) bool {
+		return s.AttrOr("value", "on") == value
+	})
+	if found.Length() == 0 {
+		return errors.NewInvalidFormValue(
+			"Radio group '%s' does not contain a radio button with the value '%s'.", name, value)
+	}
+	f.fields.Set(name, value)
+	return nil
+}
+
+// File sets the file uploaded with the file input with the given name.
+//
+// Forms containing files are always submitted using the multipart/form-data
+// format.
+func (f *Form) File(name, filename string, data io.Reader) error {
+	if _, ok := f.files[name]; ok {
+		f.files[name] = &File{
+			Filename: filename,
+			Data:     data,
+		}
+		return nil
+	}
+	return errors.NewElementNotFound(
+		"No file input found with name '%s'.", name)
+}
+
+// Values returns a copy of the form field values.
+//
+// Button values are not included because they are only submitted when the
+// button is clicked. Changing the returned values does not change the form.
+func (f *Form) Values() url.Values {
+	return copyValues(f.fields)
+}
+
+// Buttons returns a copy of the form button values, keyed by button name.
+//
+// Changing the returned values does not change the form.
+func (f *Form) Buttons() url.Values {
+	return copyValues(f.buttons)
+}
+
+// HasButtons returns whether the form contains any enabled submit buttons.
+func (f *Form) HasButtons() bool {
+	return f.submitter().Length() > 0
+}
+
+// Reset restores the form fields, buttons, and files to the values parsed from
+// the form document, discarding any changes made to the form, including the
+// controls enabled or disabled with Enable() and Disable().
+func (f *Form) Reset() {
+	f.serialize()
+}
+
+// Validate checks the form for required fields which do not have a value.
+//
+// Returns an error listing the names of the empty required fields, or nil
+// when every required field has a value. Forms with the novalidate attribute
+// are not validated, as in browsers, and always return nil.
+func (f *Form) Validate() error {
+	if _, ok := f.selection.Attr("novalidate"); ok {
+		return nil
+	}
+	empty := make([]string, 0)
+	seen := make(map[string]bool)
+	f.find("[required]").Each(func(_ int, s *goquery.Selection) {
+		name, ok := s.Attr("name")
+		if !ok || f.isDisabled(s) || seen[name] {
+			return
+		}
+		seen[name] = true
+		if f.isEmpty(name) {
+			empty = append(empty, name)
+		}
+	})
+	if len(empty) > 0 {
+		return errors.NewInvalidFormValue(
+			"Required fields are empty: '%s'.", strings.Join(empty, "', '"))
+	}
+	return nil
+}
+
+// SetValidation sets whether the form is validated before being submitted.
+//
+// When enabled, Submit(), Click(), and their variants return the error from
+// Validate() instead of submitting a form with empty required fields.
+// Validation is disabled by default.
+func (f *Form) SetValidation(enabled bool) {
+	f.validate = enabled
+}
+
+// SetPercentEncodeSpaces sets whether spaces in the submitted values are
+// encoded as "%20" instead of "+".
+//
+// Browsers encode spaces as "+" in URL encoded forms, which some servers do
+// not decode. When enabled, spaces are encoded as "%20", and every other
+// character is encoded as before. Multipart forms are not affected.
+func (f *Form) SetPercentEncodeSpaces(enabled bool) {
+	f.spaces = enabled
+}
+
 // Submit submits the form.
-// Clicks the first button in the form, or submits the form without using
-// any button when the form does not contain any buttons.
+// Clicks the first button in the form, in document order, or submits the
+// form without using any button when the form does not contain any buttons.
+//
+// Falling back to a button-less submission means a form whose buttons are
+// missing, for instance because of a typo in a selector, still submits
+// successfully. Use HasButtons() to check for buttons, or Click() to fail when
+// a specific button is missing.
+//
+// After a successful submission the response, or the page it redirected to,
+// is the current page of the browser, so there is no need to open it again.
+// As with Open(), error responses are loaded like any other page and only
+// make Submit return an error when the browser HTTPErrors attribute is set:
+// use the browser StatusCode() to check the result of the submission.
+//
+// The form refers to the page it was read from, which is the previous page
+// once the form has been submitted.
+//
+// Click() and the other Click methods load the response the same way.
 func (f *Form) Submit() error {
-	if len(f.buttons) > 0 {
-		for name := range f.buttons {
-			return f.Click(name)
-		}
+	return f.SubmitWithContext(context.Background())
+}
+
+// SubmitWithContext works like Submit, but the submission is aborted when the
+// given context is cancelled.
+func (f *Form) SubmitWithContext(ctx context.Context) error {
+	if sel := f.submitter(); sel.Length() > 0 {
+		return f.clickElement(ctx, sel)
 	}
-	return f.send("", "")
+	return f.send(ctx, f.method, f.action, nil)
 }
 
 // Click submits the form by clicking the button with the given name.
+//
+// The formaction and formmethod attributes of the button override the form
+// action and method when present. Image buttons are clicked at the
+// coordinates 0,0.
+//
+// The response becomes the current page of the browser, as described by
+// Submit().
 func (f *Form) Click(button string) error {
+	return f.ClickWithContext(context.Background(), button)
+}
+
+// ClickWithContext works like Click, but the submission is aborted when the
+// given context is cancelled.
+func (f *Form) ClickWithContext(ctx context.Context, button string) error {
 	if _, ok := f.buttons[button]; !ok {
-		return errors.NewInvalidFormValue(
+		return f.buttonNotFound(button,
 			"Form does not contain a button with the name '%s'.", button)
 	}
-	return f.send(button, f.buttons[button][0])
+	sel := f.button(button)
+	if controlType(sel) == "image" {
+		return f.ClickImageWithContext(ctx, button, 0, 0)
+	}
+	method, action, err := f.buttonAttributes(sel)
+	if err != nil {
+		return err
+	}
+	return f.send(ctx, method, action, url.Values{button: {f.buttons[button][0]}})
+}
+
+// ClickByValue submits the form by clicking the button with the given name
+// and value.
+//
+// Use ClickByValue() when several buttons share the same name, such as
+// "action" buttons with the values "save" and "delete".
+func (f *Form) ClickByValue(name, value string) error {
+	return f.ClickByValueWithContext(context.Background(), name, value)
+}
+
+// ClickByValueWithContext works like ClickByValue, but the submission is
+// aborted when the given context is cancelled.
+func (f *Form) ClickByValueWithContext(ctx context.Context, name, value string) error {
+	found := false
+	for _, v := range f.buttons[name] {
+		if v == value {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return f.buttonNotFound(name,
+			"Form does not contain a button with the name '%s' and value '%s'.", name, value)
+	}
+	sel := f.find("input,button").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		n, _ := s.Attr("name")
+		v, _ := s.Attr("value")
+		return n == name && v == value && isSubmitButton(s) && !f.isDisabled(s)
+	}).First()
+	method, action, err := f.buttonAttributes(sel)
+	if err != nil {
+		return err
+	}
+	return f.send(ctx, method, action, url.Values{name: {value}})
+}
+
+// ClickImage submits the form by clicking the image button with the given
+// name at the given coordinates.
+//
+// The coordinates are submitted as the fields "name.x" and "name.y".
+func (f *Form) ClickImage(name string, x, y int) error {
+	return f.ClickImageWithContext(context.Background(), name, x, y)
+}
+
+// ClickImageWithContext works like ClickImage, but the submission is aborted
+// when the given context is cancelled.
+func (f *Form) ClickImageWithContext(ctx context.Context, name string, x, y int) error {
+	sel := f.button(name)
+	if _, ok := f.buttons[name]; !ok || controlType(sel) != "image" {
+		return f.buttonNotFound(name,
+			"Form does not contain an image button with the name '%s'.", name)
+	}
+	method, action, err := f.buttonAttributes(sel)
+	if err != nil {
+		return err
+	}
+	return f.send(ctx, method, action, url.Values{
+		name + ".x": {strconv.Itoa(x)},
+		name + ".y": {strconv.Itoa(y)},
+	})
+}
+
+// Field returns the input, select, and textarea elements in the form with the
+// given name, and whether any were found.
+//
+// Radio buttons and checkboxes sharing a name are all returned in the
+// selection.
+func (f *Form) Field(name string) (*goquery.Selection, bool) {
+	sel := f.find("input,select,textarea").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		n, _ := s.Attr("name")
+		return n == name
+	})
+	return sel, sel.Length() > 0
 }
 
 // Dom returns the inner *goquery.Selection.
@@ -89,93 +602,591 @@ func (f *Form) Dom() *goquery.Selection {
 	return f.selection
 }
 
-// send submits the form.
-func (f *Form) send(buttonName, buttonValue string) error {
-	method, ok := f.selection.Attr("method")
-	if !ok {
-		method = "GET"
+// HTML returns the outer HTML of the form element, as parsed from the page.
+//
+// Values changed with Input() and the other form methods are not included,
+// because they do not change the form document.
+func (f *Form) HTML() (string, error) {
+	return goquery.OuterHtml(f.selection)
+}
+
+// checkboxValue returns the value of the first checkbox in the form with the
+// given name, and with the given value when value is not nil.
+func (f *Form) checkboxValue(name string, value *string) (string, error) {
+	val := ""
+	found := false
+	f.find("input[type='checkbox']").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		if n, _ := s.Attr("name"); n != name || f.isDisabled(s) {
+			return true
+		}
+		v, ok := s.Attr("value")
+		if !ok {
+			v = "on"
+		}
+		if value != nil && v != *value {
+			return true
+		}
+		val, found = v, true
+		return false
+	})
+	if !found {
+		if value != nil {
+			return "", errors.NewElementNotFound(
+				"No checkbox found with name '%s' and value '%s'.", name, *value)
+		}
+		return "", errors.NewElementNotFound(
+			"No checkbox found with name '%s'.", name)
 	}
-	action, ok := f.selection.Attr("action")
-	if !ok {
-		action = f.bow.Url().String()
+	return val, nil
+}
+
+// check adds the checkbox value to the form fields unless already present.
+func (f *Form) check(name, value string) {
+	for _, v := range f.fields[name] {
+		if v == value {
+			return
+		}
 	}
-	aurl, err := url.Parse(action)
+	f.fields.Add(name, value)
+}
+
+// uncheck removes the checkbox value from the form fields.
+func (f *Form) uncheck(name, value string) {
+	vals := make([]string, 0, len(f.fields[name]))
+	for _, v := range f.fields[name] {
+		if v != value {
+			vals = append(vals, v)
+		}
+	}
+	if len(vals) == 0 {
+		f.fields.Del(name)
+	} else {
+		f.fields[name] = vals
+	}
+}
+
+// button returns the first enabled submit button in the form with the given
+// name.
+func (f *Form) button(name string) *goquery.Selection {
+	return f.find("input,button").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		n, _ := s.Attr("name")
+		return n == name && isSubmitButton(s) && !f.isDisabled(s)
+	}).First()
+}
+
+// buttonNotFound returns the error for clicking the button with the given
+// name, which is not a submit button of the form.
+//
+// Clicking a reset button returns a specific error, and the error created
+// from the given message otherwise.
+func (f *Form) buttonNotFound(name string, msg string, a ...interface{}) error {
+	resets := f.controls(name).FilterFunction(func(_ int, s *goquery.Selection) bool {
+		return isResetButton(s)
+	})
+	if resets.Length() > 0 {
+		return errors.NewInvalidFormValue(
+			"Button '%s' is a reset button, which cannot submit the form.", name)
+	}
+	return errors.NewInvalidFormValue(msg, a...)
+}
+
+// buttonAttributes returns the method and action used when clicking the
+// given button.
+func (f *Form) buttonAttributes(sel *goquery.Selection) (string, string, error) {
+	method, action := f.method, f.action
+	if m, ok := sel.Attr("formmethod"); ok && strings.TrimSpace(m) != "" {
+		method = formMethod(f.bow, m)
+	}
+	if a, ok := sel.Attr("formaction"); ok {
+		aurl, err := url.Parse(a)
+		if err != nil {
+			return "", "", err
+		}
+		action = f.bow.ResolveUrl(aurl).String()
+	}
+	return method, action, nil
+}
+
+// submitter returns the first enabled submit button in the form, in document
+// order, which may not have a name. The selection is empty when the form does
+// not contain any submit buttons.
+func (f *Form) submitter() *goquery.Selection {
+	return f.find("input,button").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		return isSubmitButton(s) && !f.isDisabled(s)
+	}).First()
+}
+
+// clickElement submits the form by clicking the given submit button element,
+// which may not have a name.
+func (f *Form) clickElement(ctx context.Context, sel *goquery.Selection) error {
+	method, action, err := f.buttonAttributes(sel)
 	if err != nil {
 		return err
 	}
-	aurl = f.bow.ResolveUrl(aurl)
+	var values url.Values
+	if name, _ := sel.Attr("name"); name != "" {
+		if controlType(sel) == "image" {
+			values = url.Values{name + ".x": {"0"}, name + ".y": {"0"}}
+		} else {
+			value, _ := sel.Attr("value")
+			values = url.Values{name: {value}}
+		}
+	}
+	return f.send(ctx, method, action, values)
+}
 
-	values := make(url.Values, len(f.fields)+1)
+// send submits the form using the given method and action.
+// The button values are those of the clicked button, and may be nil.
+func (f *Form) send(ctx context.Context, method, action string, button url.Values) error {
+	if method == "DIALOG" {
+		return errors.NewInvalidFormValue(
+			"Cannot submit a form using the dialog method, which closes a dialog without submitting the form.")
+	}
+	if f.validate {
+		if err := f.Validate(); err != nil {
+			return err
+		}
+	}
+	values := make(url.Values, len(f.fields)+len(button))
 	for name, vals := range f.fields {
 		values[name] = vals
 	}
-	if buttonName != "" {
-		values.Set(buttonName, buttonValue)
+	for name, vals := range button {
+		values[name] = vals
+	}
+	var clicked url.Values
+	if button != nil {
+		clicked = copyValues(button)
+	}
+	err := f.bow.Events().Do(event.Submit, &event.Submission{
+		Method: method,
+		Action: action,
+		Values: copyValues(values),
+		Button: clicked,
+	})
+	if err != nil {
+		return err
 	}
 
-	if strings.ToUpper(method) == "GET" {
-		return f.bow.OpenForm(aurl.String(), values)
-	} else {
-		enctype, _ := f.selection.Attr("enctype")
-		if enctype == "multipart/form-data" {
-			return f.bow.PostMultipart(aurl.String(), values)
+	// Submissions are made from the page containing the form.
+	ctx = withReferer(ctx, f.bow.Url())
+	if method == "GET" {
+		aurl, err := url.Parse(action)
+		if err != nil {
+			return err
 		}
-		return f.bow.PostForm(aurl.String(), values)
+		// Fields are appended to any query already in the action.
+		query := f.encode(values)
+		if aurl.RawQuery != "" && query != "" {
+			aurl.RawQuery += "&" + query
+		} else if query != "" {
+			aurl.RawQuery = query
+		}
+		return f.bow.OpenWithContext(ctx, aurl.String())
+	}
+	if f.enctype == "multipart/form-data" || f.hasFiles() {
+		body, contentType, err := f.encodeMultipart(values)
+		if err != nil {
+			return err
+		}
+		return f.bow.PostWithContext(ctx, action, contentType, body)
 	}
+	return f.bow.PostWithContext(ctx, action, "application/x-www-form-urlencoded", strings.NewReader(f.encode(values)))
+}
 
-	return nil
+// encode URL encodes the given values with the fields in document order.
+func (f *Form) encode(values url.Values) string {
+	buf := &bytes.Buffer{}
+	for _, name := range f.names(values) {
+		for _, val := range values[name] {
+			if buf.Len() > 0 {
+				buf.WriteByte('&')
+			}
+			buf.WriteString(f.escape(name))
+			buf.WriteByte('=')
+			buf.WriteString(f.escape(val))
+		}
+	}
+
+	return buf.String()
+}
+
+// escape transcodes and URL encodes the given form name or value.
+func (f *Form) escape(str string) string {
+	str = url.QueryEscape(f.transcode(str))
+	if f.spaces {
+		// QueryEscape encodes plus signs, so every remaining one is a space.
+		str = strings.Replace(str, "+", "%20", -1)
+	}
+	return str
+}
+
+// encodeMultipart encodes the given values and the form files using the
+// multipart/form-data format, with the fields in document order.
+//
+// Returns the encoded body and its content type.
+func (f *Form) encodeMultipart(values url.Values) (*bytes.Buffer, string, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for _, name := range f.names(values) {
+		for _, val := range values[name] {
+			if err := writer.WriteField(f.transcode(name), f.transcode(val)); err != nil {
+				return nil, "", err
+			}
+		}
+		file := f.files[name]
+		if file == nil {
+			continue
+		}
+		fw, err := writer.CreateFormFile(f.transcode(name), f.transcode(file.Filename))
+		if err != nil {
+			return nil, "", err
+		}
+		if file.Data != nil {
+			if _, err = io.Copy(fw, file.Data); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return body, writer.FormDataContentType(), nil
+}
+
+// names returns the names of the given values and of the form files with the
+// names of the form controls in document order. Names which are not part of
+// the form document follow in sorted order.
+func (f *Form) names(values url.Values) []string {
+	names := make([]string, 0, len(values)+len(f.files))
+	seen := make(map[string]bool, len(values)+len(f.files))
+	for _, name := range f.order {
+		_, isValue := values[name]
+		_, isFile := f.files[name]
+		if (isValue || isFile) && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	rest := make([]string, 0)
+	for name := range values {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(names, rest...)
 }
 
-// Serialize converts the form fields into a url.Values type.
-// Returns two url.Value types. The first is the form field values, and the
-// second is the form button values.
-func serializeForm(sel *goquery.Selection) (url.Values, url.Values) {
-	input := sel.Find("input,button")
-	if input.Length() == 0 {
-		return url.Values{}, url.Values{}
+// transcode converts the UTF-8 string to the form character set.
+// Characters which the character set cannot represent are replaced with HTML
+// character references, which is what browsers do.
+func (f *Form) transcode(str string) string {
+	if f.charset == nil {
+		return str
+	}
+	enc := encoding.HTMLEscapeUnsupported(f.charset.NewEncoder())
+	out, err := enc.String(str)
+	if err != nil {
+		return str
 	}
+	return out
+}
 
+// isEmpty returns whether the field or file input with the given name does not
+// have a value.
+func (f *Form) isEmpty(name string) bool {
+	if file, ok := f.files[name]; ok {
+		return file == nil
+	}
+	for _, val := range f.fields[name] {
+		if val != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// hasFiles returns whether any files have been set on the form.
+func (f *Form) hasFiles() bool {
+	for _, file := range f.files {
+		if file != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// serialize reads the form field values, the form button values, and the
+// file inputs from the form selection.
+func (f *Form) serialize() {
+	f.toggled = make(map[string]bool)
+	f.fields, f.buttons, f.order, f.files = f.parse()
+}
+
+// parse returns the form field values, the form button values, the control
+// names, and the file inputs read from the form selection.
+//
+// The control names are recorded in document order so the form can be
+// submitted with the fields in the same order as a browser would.
+func (f *Form) parse() (url.Values, url.Values, []string, FileSet) {
 	fields := make(url.Values)
 	buttons := make(url.Values)
-	input.Each(func(_ int, s *goquery.Selection) {
+	order := make([]string, 0)
+	files := make(FileSet)
+	seen := make(map[string]bool)
+	f.find("input,button,textarea,select").Each(func(_ int, s *goquery.Selection) {
 		name, ok := s.Attr("name")
-		if ok {
-			typ, ok := s.Attr("type")
-			if ok {
-				if typ == "submit" {
-					val, ok := s.Attr("value")
-					if ok {
-						buttons.Add(name, val)
-					} else {
-						buttons.Add(name, "")
-					}
+		if !ok || f.isDisabled(s) {
+			return
+		}
+		if !seen[name] {
+			seen[name] = true
+			order = append(order, name)
+		}
+
+		if s.Is("textarea") {
+			fields.Add(name, s.Text())
+		} else if s.Is("select") {
+			_, multiple := s.Attr("multiple")
+			options := s.Find("option")
+			selected := options.Filter("[selected]")
+			if selected.Length() == 0 {
+				// Browsers submit the first option of a single select when no
+				// option is selected, and nothing for a multiple select.
+				if multiple {
+					return
+				}
+				selected = options.First()
+			}
+			selected.Each(func(_ int, o *goquery.Selection) {
+				if multiple {
+					fields.Add(name, optionValue(o))
+				} else {
+					fields.Set(name, optionValue(o))
+				}
+			})
+		} else {
+			typ := controlType(s)
+			if typ == "submit" || typ == "image" {
+				val, ok := s.Attr("value")
+				if ok {
+					buttons.Add(name, val)
 				} else {
+					buttons.Add(name, "")
+				}
+			} else if typ == "checkbox" || typ == "radio" {
+				// Checkboxes and radios are only submitted when checked,
+				// and browsers use "on" when the value is missing.
+				if _, ok := s.Attr("checked"); ok {
 					val, ok := s.Attr("value")
-					if ok {
+					if !ok {
+						val = "on"
+					}
+					if typ == "radio" {
+						fields.Set(name, val)
+					} else {
 						fields.Add(name, val)
 					}
 				}
+			} else if typ == "file" {
+				files[name] = nil
+			} else if typ == "reset" {
+				// Reset buttons are never submitted, and are not buttons
+				// which can be clicked.
+			} else if typ == "button" {
+				// Plain buttons are never submitted.
+			} else {
+				val, _ := s.Attr("value")
+				fields.Add(name, val)
 			}
 		}
 	})
 
-	return fields, buttons
+	return fields, buttons, order, files
 }
 
-func formAttributes(bow Browsable, s *goquery.Selection) (string, string) {
-	method, ok := s.Attr("method")
+// find returns the elements matching the given selector which belong to the
+// form, in document order.
+//
+// The elements belonging to the form are its descendants, and the elements
+// anywhere in the document associated with the form by a form attribute
+// matching the form id. Descendants associated with another form by their
+// form attribute do not belong to the form.
+func (f *Form) find(selector string) *goquery.Selection {
+	return documentRoot(f.selection).Find(selector).FilterFunction(func(_ int, s *goquery.Selection) bool {
+		owner := formOwner(s)
+		return owner.Length() > 0 && owner.Nodes[0] == f.selection.Nodes[0]
+	})
+}
+
+// controls returns the input, button, textarea, and select elements in the
+// form with the given name.
+func (f *Form) controls(name string) *goquery.Selection {
+	return f.find("input,button,textarea,select").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		n, ok := s.Attr("name")
+		return ok && n == name
+	})
+}
+
+// isDisabled returns whether the given form control is disabled, either by
+// its disabled attribute or by calling Enable() or Disable().
+func (f *Form) isDisabled(s *goquery.Selection) bool {
+	name, _ := s.Attr("name")
+	if disabled, ok := f.toggled[name]; ok {
+		return disabled
+	}
+	return isDisabled(s)
+}
+
+// copyValues returns a deep copy of the given values.
+func copyValues(v url.Values) url.Values {
+	values := make(url.Values, len(v))
+	for name, vals := range v {
+		values[name] = append([]string(nil), vals...)
+	}
+	return values
+}
+
+// formCharset returns the character set used to submit the given form.
+//
+// The first supported character set in the accept-charset attribute is used.
+// Returns nil when the form is submitted as UTF-8.
+func formCharset(s *goquery.Selection) encoding.Encoding {
+	charsets, ok := s.Attr("accept-charset")
 	if !ok {
-		method = "GET"
+		return nil
 	}
-	action, ok := s.Attr("action")
+	for _, name := range strings.FieldsFunc(charsets, func(r rune) bool {
+		return r == ' ' || r == ','
+	}) {
+		enc, err := htmlindex.Get(name)
+		if err != nil {
+			continue
+		}
+		if n, _ := htmlindex.Name(enc); n == "utf-8" {
+			return nil
+		}
+		return enc
+	}
+	return nil
+}
+
+// formOwner returns the form the given control belongs to, which is the
+// form with the id in the form attribute of the control, or the closest form
+// ancestor of the control when the control has no form attribute.
+func formOwner(s *goquery.Selection) *goquery.Selection {
+	id, ok := s.Attr("form")
 	if !ok {
+		return s.Closest("form")
+	}
+	return documentRoot(s).Find("form").FilterFunction(func(_ int, form *goquery.Selection) bool {
+		v, _ := form.Attr("id")
+		return id != "" && v == id
+	}).First()
+}
+
+// documentRoot returns the root element of the document containing the
+// given selection.
+func documentRoot(s *goquery.Selection) *goquery.Selection {
+	root := s.Parents().Last()
+	if root.Length() == 0 {
+		return s
+	}
+	return root
+}
+
+// isDisabled returns whether the given form control has the disabled attribute.
+// Browsers never submit disabled controls.
+func isDisabled(s *goquery.Selection) bool {
+	_, ok := s.Attr("disabled")
+	return ok
+}
+
+// controlType returns the lower case type of an input or button element.
+// Inputs default to "text" and buttons default to "submit" when the type
+// attribute is missing.
+func controlType(s *goquery.Selection) string {
+	typ, ok := s.Attr("type")
+	if ok {
+		return strings.ToLower(typ)
+	}
+	if s.Is("button") {
+		return "submit"
+	}
+	return "text"
+}
+
+// isSubmitButton returns whether the given element is a button submitting
+// its form when clicked.
+func isSubmitButton(s *goquery.Selection) bool {
+	if !s.Is("input,button") {
+		return false
+	}
+	typ := controlType(s)
+	return typ == "submit" || typ == "image"
+}
+
+// isResetButton returns whether the given element is a button resetting its
+// form when clicked.
+func isResetButton(s *goquery.Selection) bool {
+	return s.Is("input,button") && controlType(s) == "reset"
+}
+
+// optionValue returns the value of a select option.
+// The option text is used when the option does not have a value attribute.
+func optionValue(s *goquery.Selection) string {
+	val, ok := s.Attr("value")
+	if ok {
+		return val
+	}
+	return strings.TrimSpace(s.Text())
+}
+
+// formMethod returns the normalized value of the given method or formmethod
+// attribute, which is either "GET", "POST", or "DIALOG".
+//
+// As in browsers, a missing or unknown method is GET. Unknown methods, such
+// as misspelled methods, dispatch an event.Warn event.
+func formMethod(bow Browsable, method string) string {
+	m := strings.ToUpper(strings.TrimSpace(method))
+	switch m {
+	case "GET", "POST", "DIALOG":
+		return m
+	case "":
+		return "GET"
+	}
+	bow.Events().Do(event.Warn, &event.Warning{
+		Url:     bow.Url().String(),
+		Message: "Unknown form method '" + method + "', submitting the form using GET.",
+	})
+	return "GET"
+}
+
+// formAttributes returns the method, action, and enctype of the given form.
+func formAttributes(bow Browsable, s *goquery.Selection) (string, string, string) {
+	method, _ := s.Attr("method")
+	method = formMethod(bow, method)
+	// Browsers submit forms without an action, or with an empty action, to
+	// the page URL.
+	action, _ := s.Attr("action")
+	action = strings.TrimSpace(action)
+	if action == "" {
 		action = bow.Url().String()
 	}
+	enctype, ok := s.Attr("enctype")
+	if !ok || strings.TrimSpace(enctype) == "" {
+		enctype = "application/x-www-form-urlencoded"
+	}
+	enctype = strings.ToLower(strings.TrimSpace(enctype))
+
 	aurl, err := url.Parse(action)
 	if err != nil {
-		return "", ""
+		return method, "", enctype
 	}
 	aurl = bow.ResolveUrl(aurl)
 
-	return strings.ToUpper(method), aurl.String()
+	return method, aurl.String(), enctype
 }
@@ -1,43 +1,1129 @@
 package browser
 
 import (
+	"context"
 	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/headzoo/surf/errors"
+	"github.com/headzoo/surf/event"
 	"github.com/headzoo/surf/jar"
 	"github.com/headzoo/ut"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 )
 
 func TestBrowserForm(t *testing.T) {
 	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlForm, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+
+	f.Input("age", "55")
+	f.Input("gender", "male")
+	err = f.Click("submit2")
+	ut.AssertNil(err)
+	ut.AssertContains("age=55", bow.Body())
+	ut.AssertContains("gender=male", bow.Body())
+	ut.AssertContains("submit2=submitted2", bow.Body())
+}
+
+func TestBrowserFormCheckboxes(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlForm, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertContains("gender=female", bow.Body())
+	ut.AssertContains("music=rock", bow.Body())
+	ut.AssertFalse(strings.Contains(bow.Body(), "music=fusion"))
+	ut.AssertFalse(strings.Contains(bow.Body(), "newsletter="))
+}
+
+func TestBrowserFormCheck(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlForm, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+
+	err = f.Uncheck("music")
+	ut.AssertNil(err)
+	err = f.Check("newsletter")
+	ut.AssertNil(err)
+	err = f.Check("missing")
+	ut.AssertNotNil(err)
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertContains("newsletter=on", bow.Body())
+	ut.AssertFalse(strings.Contains(bow.Body(), "music="))
+}
+
+func TestBrowserFormCheckValue(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlForm, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+	err = f.CheckValue("music", "fusion")
+	ut.AssertNil(err)
+	ut.AssertEquals([]string{"rock", "fusion"}, f.Values()["music"])
+	err = f.Uncheck("music")
+	ut.AssertNil(err)
+	ut.AssertEquals([]string{"fusion"}, f.Values()["music"])
+	err = f.UncheckValue("music", "fusion")
+	ut.AssertNil(err)
+	ut.AssertEquals(0, len(f.Values()["music"]))
+	err = f.CheckValue("music", "jazz")
+	ut.AssertNotNil(err)
+}
+
+func TestBrowserFormSelect(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlForm, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertContains("country=uy", bow.Body())
+	ut.AssertContains("langs=go", bow.Body())
+	ut.AssertContains("langs=ruby", bow.Body())
+
+	bow.Back()
+	f, err = bow.Form("[name='default']")
+	ut.AssertNil(err)
+	err = f.SelectOption("country", "Argentina")
+	ut.AssertNil(err)
+	err = f.SelectOption("langs", "c")
+	ut.AssertNil(err)
+	err = f.SelectOption("country", "br")
+	ut.AssertNotNil(err)
+	err = f.SelectOption("missing", "uy")
+	ut.AssertNotNil(err)
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertContains("country=Argentina", bow.Body())
+	ut.AssertContains("langs=c", bow.Body())
+}
+
+func TestBrowserFormTextarea(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlForm, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+	ut.AssertEquals("Hello", f.Values().Get("message"))
+
+	err = f.Input("message", "Hello, Surf!")
+	ut.AssertNil(err)
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertContains("message=Hello%2C+Surf%21", bow.Body())
+	ut.AssertContains("comment=&", bow.Body())
+}
+
+func TestBrowserFormNoType(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormSearch, echoRaw)
+	defer ts.Close()
+
+	f, err := bow.Form("form")
+	ut.AssertNil(err)
+	err = f.Input("q", "golang")
+	ut.AssertNil(err)
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertEquals("q=golang&go=", bow.Find("body").Text())
+}
+
+func TestBrowserFormSkipsButtons(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormSearch, echoRaw)
+	defer ts.Close()
+
+	f, err := bow.Form("form")
+	ut.AssertNil(err)
+	_, ok := f.Values()["clear"]
+	ut.AssertFalse(ok)
+	_, ok = f.Values()["toggle"]
+	ut.AssertFalse(ok)
+}
+
+func TestBrowserFormDisabled(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlForm, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+	err = f.Input("token", "secret")
+	ut.AssertNotNil(err)
+	err = f.Click("submit3")
+	ut.AssertNotNil(err)
+	err = f.Check("locked")
+	ut.AssertNotNil(err)
+	err = f.SelectOption("region", "north")
+	ut.AssertNotNil(err)
+
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertFalse(strings.Contains(bow.Body(), "token="))
+	ut.AssertFalse(strings.Contains(bow.Body(), "submit3="))
+	ut.AssertFalse(strings.Contains(bow.Body(), "locked="))
+	ut.AssertFalse(strings.Contains(bow.Body(), "region="))
+}
+
+func TestBrowserFormEnable(t *testing.T) {
+	ut.Run(t)
+	var got url.Values
+	bow, ts := newFormTestBrowser(htmlForm, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		got = r.Form
+	})
+	defer ts.Close()
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+	ut.AssertNotNil(f.Enable("missing"))
+	ut.AssertNotNil(f.Disable("missing"))
+
+	ut.AssertNil(f.Enable("token"))
+	ut.AssertEquals("secret", f.Values().Get("token"))
+	ut.AssertNil(f.Enable("region"))
+	ut.AssertEquals("north", f.Values().Get("region"))
+	ut.AssertNil(f.Enable("locked"))
+	ut.AssertNil(f.Check("locked"))
+	f.Input("age", "55")
+	ut.AssertNil(f.Enable("age"))
+	ut.AssertEquals("55", f.Values().Get("age"))
+	ut.AssertNil(f.Disable("message"))
+	ut.AssertNil(f.Disable("submit1"))
+	ut.AssertNotNil(f.Click("submit1"))
+
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertEquals("secret", got.Get("token"))
+	ut.AssertEquals("north", got.Get("region"))
+	ut.AssertEquals("q", got.Get("locked"))
+	ut.AssertEquals("55", got.Get("age"))
+	_, ok := got["message"]
+	ut.AssertFalse(ok)
+	ut.AssertEquals("submitted2", got.Get("submit2"))
+
+	f.Reset()
+	_, ok = f.Values()["token"]
+	ut.AssertFalse(ok)
+	ut.AssertEquals("Hello", f.Values().Get("message"))
+}
+
+func TestBrowserFormFile(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormUpload, echoUpload)
+	defer ts.Close()
+
+	f, err := bow.Form("form")
+	ut.AssertNil(err)
+	err = f.File("avatar", "avatar.png", strings.NewReader("png-data"))
+	ut.AssertNil(err)
+	err = f.File("missing", "missing.png", strings.NewReader("png-data"))
+	ut.AssertNotNil(err)
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertEquals("joe:avatar.png:png-data", bow.Find("body").Text())
+}
+
+func TestBrowserFormEnctype(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormUpload, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("form")
+	ut.AssertNil(err)
+	ut.AssertEquals("multipart/form-data", f.Enctype())
+
+	bow, ts2 := newFormTestBrowser(htmlFormSearch, nil)
+	defer ts2.Close()
+	f, err = bow.Form("form")
+	ut.AssertNil(err)
+	ut.AssertEquals("application/x-www-form-urlencoded", f.Enctype())
+}
+
+func TestBrowserFormButtonOverrides(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormOverrides, echoRequest)
+	defer ts.Close()
+
+	f, err := bow.Form("form")
+	ut.AssertNil(err)
+	err = f.Click("save")
+	ut.AssertNil(err)
+	ut.AssertEquals("POST /save item=42&save=", bow.Find("body").Text())
+
+	bow.Back()
+	f, err = bow.Form("form")
+	ut.AssertNil(err)
+	err = f.Click("continue")
+	ut.AssertNil(err)
+	ut.AssertEquals("GET /continue continue=&item=42", bow.Find("body").Text())
+}
+
+func TestBrowserFormValues(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlForm, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+	f.Input("age", "55")
+
+	values := f.Values()
+	ut.AssertEquals("55", values.Get("age"))
+	ut.AssertEquals("", values.Get("submit1"))
+	values.Set("age", "99")
+	ut.AssertEquals("55", f.Values().Get("age"))
+}
+
+func TestBrowserFormSet(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlForm, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+	err = f.Input("csrf", "abc123")
+	ut.AssertNotNil(err)
+	f.Set("csrf", "abc123")
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertContains("csrf=abc123", bow.Body())
+}
+
+func TestBrowserFormRemoveField(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlForm, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+	err = f.RemoveField("optional")
+	ut.AssertNil(err)
+	err = f.RemoveField("optional")
+	ut.AssertNotNil(err)
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertFalse(strings.Contains(bow.Body(), "optional="))
+}
+
+func TestBrowserFormImage(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormOverrides, echoRequest)
+	defer ts.Close()
+
+	f, err := bow.Form("form")
+	ut.AssertNil(err)
+	err = f.Click("nav")
+	ut.AssertNil(err)
+	ut.AssertEquals("POST /save item=42&nav.x=0&nav.y=0", bow.Find("body").Text())
+
+	bow.Back()
+	f, err = bow.Form("form")
+	ut.AssertNil(err)
+	err = f.ClickImage("nav", 12, 34)
+	ut.AssertNil(err)
+	ut.AssertEquals("POST /save item=42&nav.x=12&nav.y=34", bow.Find("body").Text())
+	err = f.ClickImage("save", 1, 1)
+	ut.AssertNotNil(err)
+}
+
+func TestBrowserFormAddValue(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlForm, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+	f.AddValue("items[]", "1")
+	f.AddValue("items[]", "2")
+	ut.AssertEquals([]string{"a", "1", "2"}, f.Values()["items[]"])
+	err = f.Input("items[]", "b")
+	ut.AssertNil(err)
+	ut.AssertEquals([]string{"b"}, f.Values()["items[]"])
+}
+
+func TestBrowserFormSetValueAt(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlForm, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+	f.AddValue("items[]", "1")
+	f.AddValue("items[]", "2")
+	err = f.SetValueAt("items[]", 1, "x")
+	ut.AssertNil(err)
+	ut.AssertEquals([]string{"a", "x", "2"}, f.Values()["items[]"])
+	err = f.SetValueAt("items[]", 0, "b")
+	ut.AssertNil(err)
+	ut.AssertEquals([]string{"b", "x", "2"}, f.Values()["items[]"])
+
+	err = f.SetValueAt("items[]", 3, "y")
+	ut.AssertNotNil(err)
+	ut.AssertContains("out of range", err.Error())
+	err = f.SetValueAt("items[]", -1, "y")
+	ut.AssertNotNil(err)
+	err = f.SetValueAt("missing", 0, "y")
+	ut.AssertNotNil(err)
+	ut.AssertEquals([]string{"b", "x", "2"}, f.Values()["items[]"])
+
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertContains("items%5B%5D=b&items%5B%5D=x&items%5B%5D=2", bow.Find("body").Text())
+}
+
+func TestBrowserFormSubmitOrder(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlForm, nil)
+	defer ts.Close()
+
+	for i := 0; i < 10; i++ {
+		f, err := bow.Form("[name='default']")
+		ut.AssertNil(err)
+		err = f.Submit()
+		ut.AssertNil(err)
+		ut.AssertContains("submit1=submitted1", bow.Body())
+		ut.AssertFalse(strings.Contains(bow.Body(), "submit2="))
+		bow.Back()
+	}
+
+	bow, ts = newFormTestBrowser(htmlFormUnnamedButton, echoRequest)
+	defer ts.Close()
+	f, err := bow.Form("#unnamed")
+	ut.AssertNil(err)
+	ut.AssertTrue(f.HasButtons())
+	ut.AssertNil(f.Submit())
+	ut.AssertEquals("POST /first q=x", bow.Find("body").Text())
+}
+
+func TestBrowserFormFieldOrder(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormOrdered, echoRaw)
+	defer ts.Close()
+
+	f, err := bow.Form("#post")
+	ut.AssertNil(err)
+	f.Set("extra", "1")
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertEquals("zeta=1&alpha=2&mid=3&mid=4&go=&extra=1", bow.Find("body").Text())
+
+	bow.Back()
+	f, err = bow.Form("#get")
+	ut.AssertNil(err)
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertEquals("zeta=1&alpha=2&mid=3", bow.Find("body").Text())
+}
+
+func TestBrowserFormMultipartOrder(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormUpload, func(w http.ResponseWriter, r *http.Request) {
+		reader, err := r.MultipartReader()
+		if err != nil {
+			fmt.Fprint(w, err)
+			return
+		}
+		names := make([]string, 0)
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				break
+			}
+			names = append(names, part.FormName())
+		}
+		fmt.Fprint(w, strings.Join(names, ","))
+	})
+	defer ts.Close()
+
+	for i := 0; i < 5; i++ {
+		f, err := bow.Form("form")
+		ut.AssertNil(err)
+		err = f.File("avatar", "avatar.png", strings.NewReader("png-data"))
+		ut.AssertNil(err)
+		err = f.Submit()
+		ut.AssertNil(err)
+		ut.AssertEquals("user,avatar,zone,city,country", bow.Find("body").Text())
+		bow.Back()
+	}
+}
+
+func TestBrowserFormReset(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormUpload, echoUpload)
+	defer ts.Close()
+
+	f, err := bow.Form("form")
+	ut.AssertNil(err)
+	err = f.File("avatar", "avatar.png", strings.NewReader("png-data"))
+	ut.AssertNil(err)
+	f.Set("user", "bob")
+	f.Reset()
+	ut.AssertEquals("joe", f.Values().Get("user"))
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertEquals("http: no such file", bow.Find("body").Text())
+}
+
+func TestBrowserFormCharset(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormCharset, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			r.ParseMultipartForm(1024)
+			fmt.Fprint(w, "name="+url.QueryEscape(r.FormValue("name")))
+			return
+		}
+		echoRaw(w, r)
+	})
+	defer ts.Close()
+
+	f, err := bow.Form("#urlencoded")
+	ut.AssertNil(err)
+	err = f.Input("name", "café Ω")
+	ut.AssertNil(err)
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertEquals("name=caf%E9+%26%23937%3B", bow.Find("body").Text())
+
+	bow.Back()
+	f, err = bow.Form("#multipart")
+	ut.AssertNil(err)
+	err = f.Input("name", "café Ω")
+	ut.AssertNil(err)
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertEquals("name=caf%E9+%26%23937%3B", bow.Find("body").Text())
+}
+
+func TestBrowserFormButtons(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlForm, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+	buttons := f.Buttons()
+	ut.AssertEquals(2, len(buttons))
+	ut.AssertEquals("submitted1", buttons.Get("submit1"))
+	ut.AssertEquals("submitted2", buttons.Get("submit2"))
+	buttons.Del("submit1")
+	ut.AssertEquals(2, len(f.Buttons()))
+	ut.AssertTrue(f.HasButtons())
+}
+
+func TestBrowserFormHasButtons(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormActionQuery, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("#search")
+	ut.AssertNil(err)
+	ut.AssertFalse(f.HasButtons())
+	ut.AssertNotNil(f.Click("submit"))
+}
+
+func TestBrowserFormClickByValue(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormActions, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("form")
+	ut.AssertNil(err)
+	err = f.ClickByValue("action", "delete")
+	ut.AssertNil(err)
+	ut.AssertEquals("action=delete&id=7", bow.Find("body").Text())
+
+	bow.Back()
+	f, err = bow.Form("form")
+	ut.AssertNil(err)
+	err = f.ClickByValue("action", "save")
+	ut.AssertNil(err)
+	ut.AssertEquals("action=save&id=7", bow.Find("body").Text())
+	err = f.ClickByValue("action", "archive")
+	ut.AssertNotNil(err)
+}
+
+func TestBrowserFormValidate(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormRequired, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("form")
+	ut.AssertNil(err)
+	err = f.Validate()
+	ut.AssertNotNil(err)
+	ut.AssertContains("'user', 'terms'", err.Error())
+	ut.AssertFalse(strings.Contains(err.Error(), "email"))
+
+	f.Input("user", "joe")
+	f.Check("terms")
+	err = f.Validate()
+	ut.AssertNil(err)
+}
+
+func TestBrowserFormNoValidate(t *testing.T) {
+	ut.Run(t)
+	html := strings.Replace(htmlFormRequired, `<form method="post" action="/">`, `<form method="post" action="/" novalidate>`, 1)
+	bow, ts := newFormTestBrowser(html, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("form")
+	ut.AssertNil(err)
+	ut.AssertNil(f.Validate())
+	f.SetValidation(true)
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertContains("email=joe%40example.com", bow.Body())
+
+	bow, ts2 := newFormTestBrowser(htmlFormRequired, nil)
+	defer ts2.Close()
+	f, err = bow.Form("form")
+	ut.AssertNil(err)
+	ut.AssertNotNil(f.Validate())
+}
+
+func TestBrowserFormSetValidation(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormRequired, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("form")
+	ut.AssertNil(err)
+	f.SetValidation(true)
+	err = f.Submit()
+	ut.AssertNotNil(err)
+	ut.AssertEquals("Signup Form", bow.Title())
+
+	f.SetValidation(false)
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertContains("nickname=", bow.Body())
+}
+
+func TestBrowserFormIDName(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormOrdered, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("#post")
+	ut.AssertNil(err)
+	ut.AssertEquals("post", f.ID())
+	ut.AssertEquals("", f.Name())
+
+	bow, ts2 := newFormTestBrowser(htmlForm, nil)
+	defer ts2.Close()
+	f, err = bow.Form("form")
+	ut.AssertNil(err)
+	ut.AssertEquals("", f.ID())
+	ut.AssertEquals("default", f.Name())
+}
+
+func TestBrowserFormSelector(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormOrdered, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("#post")
+	ut.AssertNil(err)
+	ut.AssertEquals("post", f.ID())
+
+	_, err = bow.Form("form")
+	ut.AssertNotNil(err)
+	ut.AssertContains("matches 2 forms", err.Error())
+	_, err = bow.Form("#missing")
+	ut.AssertNotNil(err)
+	_, err = bow.Form("body")
+	ut.AssertNotNil(err)
+}
+
+func TestBrowserForms(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormOrdered, nil)
+	defer ts.Close()
+
+	forms := bow.Forms()
+	ut.AssertEquals(2, len(forms))
+	ut.AssertEquals("post", forms[0].ID())
+	ut.AssertEquals("get", forms[1].ID())
+
+	ut.AssertNil(bow.Open(ts.URL + "/empty"))
+	forms = bow.Forms()
+	ut.AssertNotNil(forms)
+	ut.AssertEquals(0, len(forms))
+}
+
+func TestBrowserClickButton(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormOverrides, echoRequest)
+	defer ts.Close()
+
+	err := bow.Click("button[name='save']")
+	ut.AssertNil(err)
+	ut.AssertEquals("POST /save item=42&save=", bow.Find("body").Text())
+
+	ut.AssertTrue(bow.Back())
+	err = bow.Click("button[name='continue']")
+	ut.AssertNil(err)
+	ut.AssertEquals("GET /continue continue=&item=42", bow.Find("body").Text())
+
+	ut.AssertTrue(bow.Back())
+	err = bow.Click("input[name='nav']")
+	ut.AssertNil(err)
+	ut.AssertEquals("POST /save item=42&nav.x=0&nav.y=0", bow.Find("body").Text())
+
+	ut.AssertTrue(bow.Back())
+	err = bow.Click("input[name='item']")
+	ut.AssertNotNil(err)
+	err = bow.Click("#missing")
+	ut.AssertNotNil(err)
+}
+
+func TestBrowserFormAction(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormActionUrls, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("#relative")
+	ut.AssertNil(err)
+	ut.AssertEquals(ts.URL+"/login", f.Action())
+
+	f, err = bow.Form("#empty")
+	ut.AssertNil(err)
+	ut.AssertEquals(ts.URL, f.Action())
+
+	f, err = bow.Form("#missing")
+	ut.AssertNil(err)
+	ut.AssertEquals(ts.URL, f.Action())
+
+	f, err = bow.Form("#absolute")
+	ut.AssertNil(err)
+	ut.AssertEquals("http://example.com/search", f.Action())
+}
+
+func TestBrowserFormActionQuery(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormActionQuery, echoRaw)
+	defer ts.Close()
+
+	f, err := bow.Form("#search")
+	ut.AssertNil(err)
+	ut.AssertNil(f.Submit())
+	ut.AssertEquals("lang=en&page=2&q=surf", bow.Find("body").Text())
+
+	ut.AssertTrue(bow.Back())
+	f, err = bow.Form("#empty")
+	ut.AssertNil(err)
+	ut.AssertNil(f.Submit())
+	ut.AssertEquals("lang=en", bow.Find("body").Text())
+}
+
+func TestBrowserFormField(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlForm, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+
+	sel, ok := f.Field("age")
+	ut.AssertTrue(ok)
+	ut.AssertEquals(1, sel.Length())
+	ut.AssertEquals("input", goquery.NodeName(sel))
+
+	sel, ok = f.Field("gender")
+	ut.AssertTrue(ok)
+	ut.AssertEquals(2, sel.Length())
+
+	sel, ok = f.Field("comment")
+	ut.AssertTrue(ok)
+	ut.AssertEquals("textarea", goquery.NodeName(sel))
+
+	sel, ok = f.Field("region")
+	ut.AssertTrue(ok)
+	ut.AssertEquals("select", goquery.NodeName(sel))
+
+	sel, ok = f.Field("missing")
+	ut.AssertFalse(ok)
+	ut.AssertEquals(0, sel.Length())
+}
+
+func TestBrowserFormSubmitWithContext(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlForm, func(w http.ResponseWriter, r *http.Request) {
+		// The server only notices the client going away once the body has
+		// been read.
+		ioutil.ReadAll(r.Body)
+		<-r.Context().Done()
+	})
+	defer ts.Close()
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = f.SubmitWithContext(ctx)
+	ut.AssertNotNil(err)
+	err = f.ClickWithContext(ctx, "submit2")
+	ut.AssertNotNil(err)
+	err = f.ClickByValueWithContext(ctx, "submit2", "submitted2")
+	ut.AssertNotNil(err)
+	ut.AssertEquals("Echo Form", bow.Title())
+
+	bow, ts2 := newFormTestBrowser(htmlFormOverrides, nil)
+	defer ts2.Close()
+	f, err = bow.Form("form")
+	ut.AssertNil(err)
+	err = f.ClickImageWithContext(ctx, "nav", 1, 2)
+	ut.AssertNotNil(err)
+	ut.AssertEquals("Checkout Form", bow.Title())
+}
+
+func TestBrowserFormSubmitEvent(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlForm, nil)
+	defer ts.Close()
+
+	var got *event.Submission
+	bow.Events().On(event.Submit, func(payload interface{}) error {
+		got = payload.(*event.Submission)
+		if got.Values.Get("age") == "" {
+			return errors.NewInvalidFormValue("age is required")
+		}
+		return nil
+	})
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+	err = f.Click("submit2")
+	ut.AssertNotNil(err)
+	ut.AssertEquals("age is required", err.Error())
+	ut.AssertEquals("Echo Form", bow.Title())
+	ut.AssertEquals("POST", got.Method)
+	ut.AssertEquals("submitted2", got.Values.Get("submit2"))
+	ut.AssertEquals(url.Values{"submit2": {"submitted2"}}, got.Button)
+	got.Values["message"][0] = "changed"
+	ut.AssertEquals("Hello", f.Values().Get("message"))
+
+	f.Input("age", "55")
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertContains("age=55", bow.Body())
+	ut.AssertEquals(url.Values{"submit1": {"submitted1"}}, got.Button)
+
+	ut.AssertTrue(bow.Back())
+	f, err = bow.Form("[name='default']")
+	ut.AssertNil(err)
+	f.Input("age", "55")
+	f.Disable("submit1")
+	f.Disable("submit2")
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertTrue(got.Button == nil)
+}
+
+func TestBrowserFormSubmitResponse(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlForm, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.URL.Path == "/done" {
+			fmt.Fprint(w, "<title>Done</title>")
+		} else if r.Form.Get("age") == "" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprint(w, "<title>Invalid</title>")
+		} else {
+			http.Redirect(w, r, "/done", http.StatusSeeOther)
+		}
+	})
+	defer ts.Close()
+	bow.SetAttributes(AttributeMap{FollowRedirects: true})
+	bow.SetMaxRedirects(10)
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertEquals(http.StatusUnprocessableEntity, bow.StatusCode())
+	ut.AssertEquals("Invalid", bow.Title())
+
+	f.Input("age", "55")
+	err = f.Click("submit2")
+	ut.AssertNil(err)
+	ut.AssertEquals(http.StatusOK, bow.StatusCode())
+	ut.AssertEquals("Done", bow.Title())
+	ut.AssertEquals("/done", bow.Url().Path)
+}
+
+func TestBrowserFormAttribute(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormAssociated, echoRequest)
+	defer ts.Close()
+
+	f, err := bow.Form("#signup")
+	ut.AssertNil(err)
+	ut.AssertEquals(url.Values{
+		"user":  {"surf"},
+		"email": {"surf@example.com"},
+		"plan":  {"pro"},
+	}, f.Values())
+	ut.AssertEquals(url.Values{"inside": {"in"}, "outside": {"out"}}, f.Buttons())
+	ut.AssertNil(f.Input("email", "other@example.com"))
+
+	err = f.Click("outside")
+	ut.AssertNil(err)
+	ut.AssertEquals("POST /signup email=other%40example.com&outside=out&plan=pro&user=surf", bow.Find("body").Text())
+
+	ut.AssertTrue(bow.Back())
+	err = bow.Click("button[name='outside']")
+	ut.AssertNil(err)
+	ut.AssertEquals("POST /signup email=surf%40example.com&outside=out&plan=pro&user=surf", bow.Find("body").Text())
+
+	ut.AssertTrue(bow.Back())
+	f, err = bow.Form("#elsewhere")
+	ut.AssertNil(err)
+	ut.AssertEquals(url.Values{"other": {"x"}}, f.Values())
+}
+
+func TestBrowserFormPercentEncodeSpaces(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormSearch, echoRaw)
+	defer ts.Close()
+
+	f, err := bow.Form("form")
+	ut.AssertNil(err)
+	f.Set("q", "surf the web+more")
+	f.Set("sort by", "a b")
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertContains("q=surf+the+web%2Bmore", bow.Body())
+
+	ut.AssertTrue(bow.Back())
+	f, err = bow.Form("form")
+	ut.AssertNil(err)
+	f.Set("q", "surf the web+more")
+	f.Set("sort by", "a b")
+	f.SetPercentEncodeSpaces(true)
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertContains("q=surf%20the%20web%2Bmore", bow.Body())
+	ut.AssertContains("sort%20by=a%20b", bow.Body())
+	ut.AssertFalse(strings.Contains(bow.Body(), "+"))
+
+	bow, ts2 := newFormTestBrowser(htmlForm, echoRaw)
+	defer ts2.Close()
+	f, err = bow.Form("[name='default']")
+	ut.AssertNil(err)
+	f.SetPercentEncodeSpaces(true)
+	f.Input("message", "hello world")
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertContains("message=hello%20world", bow.Body())
+}
+
+func TestBrowserFormHTML(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormSearch, nil)
+	defer ts.Close()
+
+	f, err := bow.Form("form")
+	ut.AssertNil(err)
+	f.Input("q", "surf")
+	html, err := f.HTML()
+	ut.AssertNil(err)
+	ut.AssertTrue(strings.HasPrefix(html, `<form action="/search">`))
+	ut.AssertTrue(strings.HasSuffix(html, "</form>"))
+	ut.AssertContains(`<input name="q"/>`, html)
+	ut.AssertFalse(strings.Contains(html, "surf"))
+	ut.AssertEquals(1, bow.Find("form").Length())
+}
+
+func TestBrowserFormPick(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormRadio, echoRaw)
+	defer ts.Close()
+
+	f, err := bow.Form("form")
+	ut.AssertNil(err)
+	ut.AssertEquals(url.Values{"size": {"m"}}, f.Values())
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertEquals("size=m", bow.Body())
+
+	ut.AssertTrue(bow.Back())
+	f, err = bow.Form("form")
+	ut.AssertNil(err)
+	ut.AssertNotNil(f.Pick("color", "purple"))
+	ut.AssertNotNil(f.Pick("color", "green"))
+	ut.AssertNotNil(f.Pick("missing", "red"))
+	ut.AssertNil(f.Pick("color", "blue"))
+	ut.AssertNil(f.Pick("size", "l"))
+	ut.AssertNil(f.Pick("agree", "on"))
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertEquals("color=blue&size=l&agree=on", bow.Find("body").Text())
+}
+
+func TestBrowserFormResetButton(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormResetButton, echoRequest)
+	defer ts.Close()
+
+	f, err := bow.Form("form")
+	ut.AssertNil(err)
+	ut.AssertEquals(url.Values{"action": {"save"}}, f.Buttons())
+	ut.AssertEquals(url.Values{"title": {"Draft"}}, f.Values())
+
+	err = f.Click("clear")
+	ut.AssertNotNil(err)
+	ut.AssertContains("'clear' is a reset button", err.Error())
+	err = f.ClickByValue("action", "undo")
+	ut.AssertNotNil(err)
+	ut.AssertContains("'action' is a reset button", err.Error())
+	err = bow.Click("#undo")
+	ut.AssertNotNil(err)
+	ut.AssertContains("reset button", err.Error())
+
+	err = f.Click("action")
+	ut.AssertNil(err)
+	ut.AssertEquals("POST /save action=save&title=Draft", bow.Find("body").Text())
+
+	ut.AssertTrue(bow.Back())
+	f, err = bow.Form("form")
+	ut.AssertNil(err)
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertEquals("POST /save action=save&title=Draft", bow.Find("body").Text())
+}
+
+func TestBrowserFormMethod(t *testing.T) {
+	ut.Run(t)
+	bow, ts := newFormTestBrowser(htmlFormMethods, echoRequest)
+	defer ts.Close()
+
+	warnings := []*event.Warning{}
+	bow.Events().On(event.Warn, func(payload interface{}) error {
+		warnings = append(warnings, payload.(*event.Warning))
+		return nil
+	})
+
+	f, err := bow.Form("#typo")
+	ut.AssertNil(err)
+	ut.AssertEquals("GET", f.Method())
+	ut.AssertEquals(1, len(warnings))
+	ut.AssertContains("'PSOT'", warnings[0].Message)
+	ut.AssertEquals(ts.URL, warnings[0].Url)
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertEquals("GET /typo q=1", bow.Body())
+
+	ut.AssertTrue(bow.Back())
+	f, err = bow.Form("#spaced")
+	ut.AssertNil(err)
+	ut.AssertEquals("POST", f.Method())
+	ut.AssertEquals(1, len(warnings))
+	err = f.Click("put")
+	ut.AssertNil(err)
+	ut.AssertEquals(2, len(warnings))
+	ut.AssertContains("'putt'", warnings[1].Message)
+	ut.AssertEquals("GET /spaced put=&q=2", bow.Find("body").Text())
+
+	ut.AssertTrue(bow.Back())
+	f, err = bow.Form("#dialog")
+	ut.AssertNil(err)
+	ut.AssertEquals("DIALOG", f.Method())
+	err = f.Submit()
+	ut.AssertNotNil(err)
+	ut.AssertContains("dialog method", err.Error())
+	ut.AssertEquals("Method Forms", bow.Title())
+	err = f.Click("save")
+	ut.AssertNil(err)
+	ut.AssertEquals("POST /dialog q=3&save=", bow.Find("body").Text())
+}
+
+// newFormTestBrowser starts a server which serves the given html from "/" and
+// passes every other request to the submit handler, and returns a browser
+// which has opened the page.
+//
+// The submit handler defaults to echoForm when nil. Callers must close the
+// returned server.
+func newFormTestBrowser(html string, submit http.HandlerFunc) (*Browser, *httptest.Server) {
+	if submit == nil {
+		submit = echoForm
+	}
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == "GET" {
-			fmt.Fprint(w, htmlForm)
+		if r.Method == "GET" && r.URL.Path == "/" && r.URL.RawQuery == "" {
+			fmt.Fprint(w, html)
 		} else {
-			r.ParseForm()
-			fmt.Fprint(w, r.Form.Encode())
+			submit(w, r)
 		}
 	}))
-	defer ts.Close()
 
 	bow := &Browser{}
 	bow.headers = make(http.Header, 10)
 	bow.history = jar.NewMemoryHistory()
-
 	err := bow.Open(ts.URL)
 	ut.AssertNil(err)
 
-	f, err := bow.Form("[name='default']")
-	ut.AssertNil(err)
+	return bow, ts
+}
 
-	f.Input("age", "55")
-	f.Input("gender", "male")
-	err = f.Click("submit2")
-	ut.AssertNil(err)
-	ut.AssertContains("age=55", bow.Body())
-	ut.AssertContains("gender=male", bow.Body())
-	ut.AssertContains("submit2=submitted2", bow.Body())
+// echoForm writes the submitted form values, sorted by name.
+func echoForm(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	fmt.Fprint(w, r.Form.Encode())
+}
+
+// echoRequest writes the method, path, and sorted form values of the request.
+func echoRequest(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	fmt.Fprintf(w, "%s %s %s", r.Method, r.URL.Path, r.Form.Encode())
+}
+
+// echoRaw writes the raw query of GET requests, and the raw body of all other
+// requests.
+func echoRaw(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" {
+		fmt.Fprint(w, r.URL.RawQuery)
+		return
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	fmt.Fprint(w, string(body))
+}
+
+// echoUpload writes the "user" field and the name and contents of the "avatar"
+// file of a multipart request.
+func echoUpload(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseMultipartForm(1024)
+	if err != nil {
+		fmt.Fprint(w, err)
+		return
+	}
+	file, header, err := r.FormFile("avatar")
+	if err != nil {
+		fmt.Fprint(w, err)
+		return
+	}
+	data, _ := ioutil.ReadAll(file)
+	fmt.Fprintf(w, "%s:%s:%s", r.FormValue("user"), header.Filename, data)
 }
 
 var htmlForm = `<!doctype html>
@@ -49,9 +1135,272 @@ var htmlForm = `<!doctype html>
 		<form method="post" action="/" name="default">
 			<input type="text" name="age" value="" />
 			<input type="radio" name="gender" value="male" />
-			<input type="radio" name="gender" value="female" />
+			<input type="radio" name="gender" value="female" checked />
+			<input type="checkbox" name="music" value="rock" checked />
+			<input type="checkbox" name="music" value="fusion" />
+			<input type="checkbox" name="newsletter" />
+			<textarea name="message">Hello</textarea>
+			<textarea name="comment"></textarea>
+			<select name="country">
+				<option value="uy">Uruguay</option>
+				<option>Argentina</option>
+			</select>
+			<select name="langs" multiple>
+				<option value="go" selected>Go</option>
+				<option value="ruby" selected>Ruby</option>
+				<option value="c">C</option>
+			</select>
 			<input type="submit" name="submit1" value="submitted1" />
 			<input type="submit" name="submit2" value="submitted2" />
+			<input type="submit" name="submit3" value="submitted3" disabled />
+			<input type="hidden" name="token" value="secret" disabled />
+			<input type="hidden" name="optional" value="" />
+			<input type="hidden" name="items[]" value="a" />
+			<input type="checkbox" name="locked" value="q" disabled />
+			<select name="region" disabled>
+				<option>north</option>
+			</select>
+		</form>
+	</body>
+</html>
+`
+
+var htmlFormResetButton = `<!doctype html>
+<html>
+	<head>
+		<title>Reset Button Form</title>
+	</head>
+	<body>
+		<form method="post" action="/save">
+			<input name="title" value="Draft" />
+			<input type="reset" name="clear" value="Clear" />
+			<button type="reset" id="undo" name="action" value="undo" formaction="/undo">Undo</button>
+			<button type="submit" name="action" value="save">Save</button>
+		</form>
+	</body>
+</html>
+`
+
+var htmlFormMethods = `<!doctype html>
+<html>
+	<head>
+		<title>Method Forms</title>
+	</head>
+	<body>
+		<form id="typo" method="PSOT" action="/typo">
+			<input name="q" value="1" />
+		</form>
+		<form id="spaced" method=" post " action="/spaced">
+			<input name="q" value="2" />
+			<button name="put" formmethod="putt">Put</button>
+		</form>
+		<form id="dialog" method="dialog" action="/dialog">
+			<input name="q" value="3" />
+			<button name="close">Close</button>
+			<button name="save" formmethod="post">Save</button>
+		</form>
+	</body>
+</html>
+`
+
+var htmlFormSearch = `<!doctype html>
+<html>
+	<head>
+		<title>Search Form</title>
+	</head>
+	<body>
+		<form action="/search">
+			<input name="q" />
+			<button name="go">Search</button>
+			<button type="button" name="toggle">Options</button>
+			<input type="reset" name="clear" value="Reset" />
+		</form>
+	</body>
+</html>
+`
+
+var htmlFormUpload = `<!doctype html>
+<html>
+	<head>
+		<title>Upload Form</title>
+	</head>
+	<body>
+		<form method="post" action="/upload" enctype="Multipart/Form-Data">
+			<input type="hidden" name="user" value="joe" />
+			<input type="file" name="avatar" />
+			<input type="hidden" name="zone" value="3" />
+			<input type="hidden" name="city" value="mvd" />
+			<input type="hidden" name="country" value="uy" />
+		</form>
+	</body>
+</html>
+`
+
+var htmlFormOverrides = `<!doctype html>
+<html>
+	<head>
+		<title>Checkout Form</title>
+	</head>
+	<body>
+		<form method="post" action="/save">
+			<input type="hidden" name="item" value="42" />
+			<button type="submit" name="save">Save</button>
+			<input type="image" name="nav" src="/nav.png" />
+			<button type="submit" name="continue" formaction="/continue" formmethod="get">Save and continue</button>
+		</form>
+	</body>
+</html>
+`
+
+var htmlFormOrdered = `<!doctype html>
+<html>
+	<head>
+		<title>Ordered Form</title>
+	</head>
+	<body>
+		<form method="post" action="/" id="post">
+			<input type="hidden" name="zeta" value="1" />
+			<textarea name="alpha">2</textarea>
+			<select name="mid" multiple>
+				<option selected>3</option>
+				<option selected>4</option>
+			</select>
+			<button name="go">Go</button>
+		</form>
+		<form method="get" action="/" id="get">
+			<input type="hidden" name="zeta" value="1" />
+			<input type="hidden" name="alpha" value="2" />
+			<input type="hidden" name="mid" value="3" />
+		</form>
+	</body>
+</html>
+`
+
+var htmlFormCharset = `<!doctype html>
+<html>
+	<head>
+		<title>Latin Form</title>
+	</head>
+	<body>
+		<form method="post" action="/" accept-charset="ISO-8859-1" id="urlencoded">
+			<input type="text" name="name" value="" />
+		</form>
+		<form method="post" action="/" accept-charset="ISO-8859-1" enctype="multipart/form-data" id="multipart">
+			<input type="text" name="name" value="" />
+		</form>
+	</body>
+</html>
+`
+
+var htmlFormActions = `<!doctype html>
+<html>
+	<head>
+		<title>Admin Form</title>
+	</head>
+	<body>
+		<form method="post" action="/">
+			<input type="hidden" name="id" value="7" />
+			<button type="submit" name="action" value="save">Save</button>
+			<button type="submit" name="action" value="delete">Delete</button>
+		</form>
+	</body>
+</html>
+`
+
+var htmlFormRequired = `<!doctype html>
+<html>
+	<head>
+		<title>Signup Form</title>
+	</head>
+	<body>
+		<form method="post" action="/">
+			<input type="text" name="user" required />
+			<input type="email" name="email" value="joe@example.com" required />
+			<input type="checkbox" name="terms" required />
+			<input type="text" name="nickname" />
+		</form>
+	</body>
+</html>
+`
+
+var htmlFormActionUrls = `<!doctype html>
+<html>
+	<head>
+		<title>Action Forms</title>
+	</head>
+	<body>
+		<form action="/login" id="relative"></form>
+		<form action="" id="empty"></form>
+		<form id="missing"></form>
+		<form action="http://example.com/search" id="absolute"></form>
+	</body>
+</html>
+`
+
+var htmlFormActionQuery = `<!doctype html>
+<html>
+	<head>
+		<title>Action Query Forms</title>
+	</head>
+	<body>
+		<form method="get" action="/search?lang=en&amp;page=2" id="search">
+			<input type="text" name="q" value="surf" />
+		</form>
+		<form method="get" action="/search?lang=en" id="empty"></form>
+	</body>
+</html>
+`
+
+var htmlFormUnnamedButton = `<!doctype html>
+<html>
+	<head>
+		<title>Unnamed Button Form</title>
+	</head>
+	<body>
+		<form method="post" action="/" id="unnamed">
+			<input type="text" name="q" value="x" />
+			<button formaction="/first">Go</button>
+			<input type="submit" name="other" value="o" formaction="/second" />
+		</form>
+	</body>
+</html>
+`
+
+var htmlFormAssociated = `<!doctype html>
+<html>
+	<head>
+		<title>Associated Form</title>
+	</head>
+	<body>
+		<form id="signup" action="/signup" method="post">
+			<input type="text" name="user" value="surf" />
+			<input type="text" name="other" value="x" form="elsewhere" />
+			<input type="submit" name="inside" value="in" />
+		</form>
+		<input type="email" name="email" value="surf@example.com" form="signup" />
+		<select name="plan" form="signup">
+			<option selected>pro</option>
+		</select>
+		<button type="submit" name="outside" value="out" form="signup">Sign up</button>
+		<input type="text" name="unowned" value="y" form="missing" />
+		<form id="elsewhere" action="/elsewhere"></form>
+	</body>
+</html>
+`
+
+var htmlFormRadio = `<!doctype html>
+<html>
+	<head>
+		<title>Radio Form</title>
+	</head>
+	<body>
+		<form method="post" action="/order">
+			<input type="radio" name="color" value="red" />
+			<input type="radio" name="color" value="blue" />
+			<input type="radio" name="color" value="green" disabled />
+			<input type="radio" name="size" value="m" checked />
+			<input type="radio" name="size" value="l" />
+			<input type="radio" name="agree" />
 		</form>
 	</body>
 </html>
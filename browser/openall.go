@@ -0,0 +1,95 @@
+package browser
+
+import (
+	"github.com/headzoo/surf/errors"
+	"sync"
+)
+
+// Result has the result of opening one of the URLs given to OpenAll.
+type Result struct {
+	// Url is the URL which was opened.
+	Url string
+
+	// StatusCode is the status code of the response, or zero when the
+	// request failed.
+	StatusCode int
+
+	// Body is the raw body of the response.
+	Body []byte
+
+	// Error contains any error that occurred opening the URL or nil.
+	Error error
+}
+
+// OpenAll opens the given URLs concurrently, using at most the given number
+// of requests at a time, and returns the results in the order of the URLs.
+//
+// Each worker uses a clone of the browser sharing its cookie jar, so the
+// session cookies are sent with every request, and the cookies set by the
+// responses are added to the browser. The cookie jar must be safe for
+// concurrent use, which the default jar is. The rate limit, cache, and
+// connection pool are shared as described by Clone. Event listeners are not
+// called, and meta refreshes are not followed.
+//
+// The current page and history of the browser are not changed, and the pages
+// loaded are recorded as visited.
+func (bow *Browser) OpenAll(urls []string, concurrency int) []Result {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > len(urls) {
+		concurrency = len(urls)
+	}
+	results := make([]Result, len(urls))
+	workers := make([]*Browser, concurrency)
+	jobs := make(chan int)
+	wg := sync.WaitGroup{}
+	for i := range workers {
+		worker := bow.Clone()
+		worker.cookies = bow.cookies
+		worker.attributes[MetaRefreshHandling] = false
+		workers[i] = worker
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				results[j] = worker.openResult(urls[j])
+			}
+		}()
+	}
+	for j := range urls {
+		jobs <- j
+	}
+	close(jobs)
+	wg.Wait()
+
+	// The cookies set while the workers were running are recorded so
+	// SaveCookies saves them, and the pages they loaded are visited.
+	for _, worker := range workers {
+		for key, c := range worker.cookieLog {
+			if bow.cookieLog == nil {
+				bow.cookieLog = make(map[string]*savedCookie)
+			}
+			bow.cookieLog[key] = c
+		}
+		for _, u := range worker.visited {
+			bow.recordVisited(u)
+		}
+	}
+	return results
+}
+
+// openResult opens the given URL and returns the result.
+func (bow *Browser) openResult(u string) Result {
+	result := Result{Url: u}
+	if result.Error = bow.Open(u); result.Error != nil {
+		if err, ok := result.Error.(errors.HTTPError); ok {
+			result.StatusCode = err.StatusCode
+			result.Body = err.Body
+		}
+		return result
+	}
+	result.StatusCode = bow.StatusCode()
+	result.Body = bow.RawBody()
+	return result
+}
@@ -0,0 +1,68 @@
+package browser
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter limits the number of requests made to each host within an
+// interval.
+//
+// A rateLimiter is safe for concurrent use.
+type rateLimiter struct {
+	// perHost is the number of requests allowed to each host per interval.
+	perHost int
+
+	// interval is the duration of the sliding window requests are counted in.
+	interval time.Duration
+
+	mu sync.Mutex
+
+	// hits holds the times of the recent requests made to each host.
+	hits map[string][]time.Time
+}
+
+// newRateLimiter creates and returns a *rateLimiter type.
+func newRateLimiter(perHost int, interval time.Duration) *rateLimiter {
+	return &rateLimiter{
+		perHost:  perHost,
+		interval: interval,
+		hits:     make(map[string][]time.Time),
+	}
+}
+
+// wait blocks until a request to the given host is allowed, or until the
+// context is cancelled.
+func (rl *rateLimiter) wait(ctx context.Context, host string) error {
+	for {
+		delay := rl.reserve(host)
+		if delay <= 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// reserve records a request to the given host when one is allowed, and
+// otherwise returns how long to wait before trying again.
+func (rl *rateLimiter) reserve(host string) time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	hits := rl.hits[host]
+	for len(hits) > 0 && now.Sub(hits[0]) >= rl.interval {
+		hits = hits[1:]
+	}
+	if len(hits) >= rl.perHost {
+		rl.hits[host] = hits
+		return rl.interval - now.Sub(hits[0])
+	}
+	rl.hits[host] = append(hits, now)
+	return 0
+}
@@ -0,0 +1,85 @@
+package browser
+
+import (
+	"net/http"
+	"strings"
+)
+
+// RedirectMethodPolicy returns the method used to follow a redirect with the
+// given status code, for a request sent with the given method.
+//
+// The body of the request is sent again when the method is kept, and dropped
+// when the method is changed.
+type RedirectMethodPolicy func(status int, method string) string
+
+// DefaultRedirectMethodPolicy is the redirect method policy used by a Browser
+// unless another policy is set with SetRedirectMethodPolicy.
+//
+// It follows the Fetch standard: 303 redirects change the method to GET,
+// except for HEAD requests, 301 and 302 redirects change POST requests to
+// GET, and 307 and 308 redirects keep the method and the body.
+func DefaultRedirectMethodPolicy(status int, method string) string {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound:
+		if method == "POST" {
+			return "GET"
+		}
+	case http.StatusSeeOther:
+		if method != "GET" && method != "HEAD" {
+			return "GET"
+		}
+	}
+	return method
+}
+
+// redirectBodyHeaders holds the headers describing the body of a request,
+// which are removed when a redirect drops the body.
+var redirectBodyHeaders = []string{
+	"Content-Encoding",
+	"Content-Language",
+	"Content-Length",
+	"Content-Location",
+	"Content-Type",
+}
+
+// redirectMethod changes the method and body of the given redirect request,
+// prepared by the http.Client following the redirect of the previous request,
+// to the ones decided by the redirect method policy.
+func (bow *Browser) redirectMethod(req, prev *http.Request) error {
+	policy := bow.redirectPolicy
+	if policy == nil {
+		policy = DefaultRedirectMethodPolicy
+	}
+	status := 0
+	if req.Response != nil {
+		status = req.Response.StatusCode
+	}
+	method := strings.ToUpper(policy(status, prev.Method))
+	if method == "" {
+		method = prev.Method
+	}
+	req.Method = method
+
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	req.Body, req.GetBody, req.ContentLength = nil, nil, 0
+	if method != prev.Method || prev.GetBody == nil {
+		for _, name := range redirectBodyHeaders {
+			req.Header.Del(name)
+		}
+		return nil
+	}
+
+	body, err := prev.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body, req.GetBody, req.ContentLength = body, prev.GetBody, prev.ContentLength
+	for _, name := range redirectBodyHeaders {
+		if v, ok := prev.Header[name]; ok {
+			req.Header[name] = v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package browser
+
+import (
+	"context"
+	"net/url"
+)
+
+// referrerPolicies holds the referrer policies supported by SetReferrerPolicy.
+var referrerPolicies = map[string]bool{
+	"no-referrer":                     true,
+	"no-referrer-when-downgrade":      true,
+	"origin":                          true,
+	"origin-when-cross-origin":        true,
+	"same-origin":                     true,
+	"strict-origin":                   true,
+	"strict-origin-when-cross-origin": true,
+	"unsafe-url":                      true,
+}
+
+// DefaultReferrerPolicy is the referrer policy used by a Browser unless
+// another policy is set with SetReferrerPolicy.
+const DefaultReferrerPolicy = "no-referrer-when-downgrade"
+
+// refererKey is the context key for the URL of the page a request is made
+// from.
+type refererKey struct{}
+
+// withReferer returns a copy of the context carrying the URL of the page a
+// request is made from, which is used as the Referer of requests made with
+// the context.
+func withReferer(ctx context.Context, ref *url.URL) context.Context {
+	return context.WithValue(ctx, refererKey{}, ref)
+}
+
+// refererFrom returns the URL of the page a request using the given context
+// is made from, or nil.
+func refererFrom(ctx context.Context) *url.URL {
+	ref, _ := ctx.Value(refererKey{}).(*url.URL)
+	return ref
+}
+
+// referrer returns the Referer header value sent by a request to target made
+// from the page at ref, using the given referrer policy, as defined by the
+// W3C Referrer Policy specification.
+//
+// Returns an empty string when no Referer should be sent.
+func referrer(policy string, ref, target *url.URL) string {
+	if ref == nil || (ref.Scheme != "http" && ref.Scheme != "https") {
+		return ""
+	}
+	full := *ref
+	full.User = nil
+	full.Fragment = ""
+	origin := url.URL{Scheme: ref.Scheme, Host: ref.Host, Path: "/"}
+	sameOrigin := ref.Scheme == target.Scheme && ref.Host == target.Host
+	downgrade := ref.Scheme == "https" && target.Scheme != "https"
+
+	switch policy {
+	case "no-referrer":
+		return ""
+	case "origin":
+		return origin.String()
+	case "origin-when-cross-origin":
+		if sameOrigin {
+			return full.String()
+		}
+		return origin.String()
+	case "same-origin":
+		if sameOrigin {
+			return full.String()
+		}
+		return ""
+	case "strict-origin":
+		if downgrade {
+			return ""
+		}
+		return origin.String()
+	case "strict-origin-when-cross-origin":
+		if sameOrigin {
+			return full.String()
+		}
+		if downgrade {
+			return ""
+		}
+		return origin.String()
+	case "unsafe-url":
+		return full.String()
+	default:
+		if downgrade {
+			return ""
+		}
+		return full.String()
+	}
+}
@@ -0,0 +1,302 @@
+package browser
+
+import (
+	"bufio"
+	"context"
+	"github.com/headzoo/surf/errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// robotsCache holds the robots.txt rules of the sites visited by a browser,
+// keyed by the origin of the site, such as "https://example.com:8443".
+//
+// The cache is shared with clones of the browser, so it is safe for
+// concurrent use.
+type robotsCache struct {
+	mu    sync.Mutex
+	sites map[string]*robotsSite
+}
+
+// robotsSite holds the robots.txt file of a site, which is fetched once.
+type robotsSite struct {
+	once sync.Once
+	file *robotsFile
+}
+
+// newRobotsCache creates and returns a *robotsCache type.
+func newRobotsCache() *robotsCache {
+	return &robotsCache{sites: make(map[string]*robotsSite)}
+}
+
+// site returns the entry of the site with the given origin, creating it when
+// the site has not been visited.
+func (rc *robotsCache) site(origin string) *robotsSite {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	site, ok := rc.sites[origin]
+	if !ok {
+		site = &robotsSite{}
+		rc.sites[origin] = site
+	}
+	return site
+}
+
+// robotsFile is a parsed robots.txt file.
+type robotsFile struct {
+	groups []*robotsGroup
+}
+
+// robotsRules are the rules of a robots.txt file applying to a user agent.
+type robotsRules struct {
+	// rules are the allow and disallow rules, in the order of the file.
+	rules []robotsRule
+
+	// limiter enforces the crawl delay of the site, and is nil when the site
+	// does not have a crawl delay.
+	limiter *rateLimiter
+}
+
+// robotsRule is an allow or disallow rule of a robots.txt file.
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// robotsGroup is a group of rules of a robots.txt file, applying to the user
+// agents of the group.
+type robotsGroup struct {
+	agents []string
+	rules  []robotsRule
+
+	// limiter enforces the crawl delay of the group, and is nil when the
+	// group does not have a crawl delay.
+	limiter *rateLimiter
+}
+
+// robotsAllowAll is the file of sites which do not have a robots.txt file.
+var robotsAllowAll = &robotsFile{}
+
+// robotsDisallowAll is the file of sites whose robots.txt file cannot be
+// fetched because of a server or network error.
+var robotsDisallowAll = &robotsFile{groups: []*robotsGroup{{
+	agents: []string{"*"},
+	rules:  []robotsRule{{allow: false, pattern: "/"}},
+}}}
+
+// parseRobots parses the given robots.txt file, as described by RFC 9309.
+//
+// Crawl-delay lines, which are not part of the RFC, are read as the number of
+// seconds to wait between requests.
+func parseRobots(r io.Reader) *robotsFile {
+	groups := make([]*robotsGroup, 0)
+	var group *robotsGroup
+	inRules := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:i]))
+		value := strings.TrimSpace(line[i+1:])
+		switch key {
+		case "user-agent":
+			// Consecutive user-agent lines start a single group.
+			if group == nil || inRules {
+				group = &robotsGroup{}
+				groups = append(groups, group)
+				inRules = false
+			}
+			group.agents = append(group.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			if group == nil {
+				continue
+			}
+			inRules = true
+			if value != "" {
+				group.rules = append(group.rules, robotsRule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			if group == nil {
+				continue
+			}
+			inRules = true
+			if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+				group.limiter = newRateLimiter(1, time.Duration(secs*float64(time.Second)))
+			}
+		}
+	}
+	return &robotsFile{groups: groups}
+}
+
+// rules returns the rules of the file applying to the given user agent.
+//
+// The groups naming a product token contained in the user agent apply to it,
+// ignoring case, or the "*" groups when none do. The rules of the groups are
+// combined, and the longest crawl delay of the groups is used.
+func (rf *robotsFile) rules(userAgent string) *robotsRules {
+	userAgent = strings.ToLower(userAgent)
+	matched, defaults := make([]*robotsGroup, 0), make([]*robotsGroup, 0)
+	for _, g := range rf.groups {
+		for _, agent := range g.agents {
+			if agent == "*" {
+				defaults = append(defaults, g)
+				break
+			}
+			if agent != "" && strings.Contains(userAgent, agent) {
+				matched = append(matched, g)
+				break
+			}
+		}
+	}
+	if len(matched) == 0 {
+		matched = defaults
+	}
+	rules := &robotsRules{}
+	for _, g := range matched {
+		rules.rules = append(rules.rules, g.rules...)
+		if g.limiter != nil && (rules.limiter == nil || g.limiter.interval > rules.limiter.interval) {
+			rules.limiter = g.limiter
+		}
+	}
+	return rules
+}
+
+// allowed returns whether the rules allow requesting the given URL.
+//
+// The rule with the longest pattern matching the path and query of the URL
+// decides, and allow rules win over disallow rules with patterns of the same
+// length. URLs not matched by any rule are allowed, and so is the robots.txt
+// file.
+func (rr *robotsRules) allowed(u *url.URL) bool {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	allowed, length := true, -1
+	for _, rule := range rr.rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > length || (n == length && rule.allow) {
+			allowed, length = rule.allow, n
+		}
+	}
+	return allowed
+}
+
+// robotsMatch returns whether the given robots.txt pattern matches the given
+// path. The "*" wildcard matches any sequence of characters, and a trailing
+// "$" matches the end of the path.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = pattern[:len(pattern)-1]
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			return len(path)-len(part) >= pos && strings.HasSuffix(path, part)
+		}
+		j := strings.Index(path[pos:], part)
+		if j < 0 {
+			return false
+		}
+		pos += j + len(part)
+	}
+	return !anchored || pos == len(path)
+}
+
+// robotsOrigin returns the origin of the given URL, which identifies the site
+// the robots.txt file applies to.
+func robotsOrigin(u *url.URL) string {
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+// robotsRules returns the robots.txt rules of the site of the given request
+// applying to the user agent of the request, fetching the robots.txt file
+// when the site has not been visited.
+func (bow *Browser) robotsRules(req *http.Request) *robotsRules {
+	site := bow.robots.site(robotsOrigin(req.URL))
+	site.once.Do(func() {
+		site.file = bow.fetchRobots(req)
+	})
+	return site.file.rules(req.Header.Get("User-Agent"))
+}
+
+// fetchRobots requests the robots.txt file of the site of the given request.
+//
+// Sites responding with a client error, such as 404 Not Found, allow every
+// request. Sites responding with a server error, or which cannot be reached,
+// disallow every request.
+func (bow *Browser) fetchRobots(req *http.Request) *robotsFile {
+	u := &url.URL{Scheme: req.URL.Scheme, Host: req.URL.Host, Path: "/robots.txt"}
+	// The file is fetched without the context of the request, because the
+	// rules are kept even when the request is cancelled.
+	rreq, err := http.NewRequestWithContext(context.Background(), "GET", u.String(), nil)
+	if err != nil {
+		return robotsDisallowAll
+	}
+	rreq.Header.Set("User-Agent", req.Header.Get("User-Agent"))
+	client := &http.Client{Transport: bow.buildTransport(), Timeout: bow.timeout}
+	resp, err := client.Do(rreq)
+	if err != nil {
+		return robotsDisallowAll
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return robotsDisallowAll
+	}
+	if resp.StatusCode != http.StatusOK {
+		return robotsAllowAll
+	}
+	// RFC 9309 requires parsing at least 500 kibibytes of the file.
+	return parseRobots(io.LimitReader(resp.Body, 512*1024))
+}
+
+// checkRobots returns an errors.RobotsDisallowed error when the browser
+// respects robots.txt files, and the file of the site of the given request
+// disallows it.
+func (bow *Browser) checkRobots(req *http.Request) error {
+	if bow.robots == nil || (req.URL.Scheme != "http" && req.URL.Scheme != "https") {
+		return nil
+	}
+	if !bow.robotsRules(req).allowed(req.URL) {
+		return errors.NewRobotsDisallowed(
+			"The robots.txt file of '%s' disallows requesting '%s'.", robotsOrigin(req.URL), req.URL.String())
+	}
+	return nil
+}
+
+// robotsDelay blocks until the crawl delay of the site of the given request
+// allows sending it, when the browser respects robots.txt files.
+func (bow *Browser) robotsDelay(req *http.Request) error {
+	if bow.robots == nil || (req.URL.Scheme != "http" && req.URL.Scheme != "https") {
+		return nil
+	}
+	rules := bow.robotsRules(req)
+	if rules.limiter == nil {
+		return nil
+	}
+	return rules.limiter.wait(req.Context(), req.URL.Host)
+}
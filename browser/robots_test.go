@@ -0,0 +1,69 @@
+package browser
+
+import (
+	"github.com/headzoo/ut"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRobotsRules(t *testing.T) {
+	ut.Run(t)
+	robots := `# Rules for everyone.
+User-agent: *
+Disallow: /private
+Allow: /private/public
+Disallow: /*.pdf$
+Disallow: /search?*q=
+Crawl-delay: 1.5
+
+User-agent: SurfBot
+User-agent: OtherBot
+Disallow: /bots
+Allow: /bots/
+Allow: /private
+
+user-agent: surfbot
+disallow: /more # merged with the other SurfBot group
+`
+	tests := []struct {
+		agent   string
+		path    string
+		allowed bool
+	}{
+		{"Mozilla/5.0", "/", true},
+		{"Mozilla/5.0", "/private", false},
+		{"Mozilla/5.0", "/private/secret", false},
+		{"Mozilla/5.0", "/private/public/page", true},
+		{"Mozilla/5.0", "/docs/file.pdf", false},
+		{"Mozilla/5.0", "/docs/file.pdf?dl=1", true},
+		{"Mozilla/5.0", "/search?lang=en&q=surf", false},
+		{"Mozilla/5.0", "/search?lang=en", true},
+		{"Mozilla/5.0", "/bots", true},
+		{"Mozilla/5.0 (compatible; surfbot/1.0)", "/private", true},
+		{"Mozilla/5.0 (compatible; surfbot/1.0)", "/bots", false},
+		{"Mozilla/5.0 (compatible; surfbot/1.0)", "/bots/page", true},
+		{"Mozilla/5.0 (compatible; surfbot/1.0)", "/more", false},
+		{"Mozilla/5.0 (compatible; surfbot/1.0)", "/file.pdf", true},
+		{"OtherBot", "/bots", false},
+		{"OtherBot", "/more", true},
+		{"Mozilla/5.0 (compatible; surfbot/1.0)", "/robots.txt", true},
+	}
+	for _, test := range tests {
+		rules := parseRobots(strings.NewReader(robots)).rules(test.agent)
+		u, err := url.Parse("http://example.com" + test.path)
+		ut.AssertNil(err)
+		ut.AssertEquals(test.allowed, rules.allowed(u))
+	}
+
+	file := parseRobots(strings.NewReader(robots))
+	rules := file.rules("Mozilla/5.0")
+	ut.AssertEquals(1500*time.Millisecond, rules.limiter.interval)
+	ut.AssertTrue(rules.limiter == file.rules("Chrome").limiter)
+	ut.AssertTrue(file.rules("SurfBot").limiter == nil)
+	u, _ := url.Parse("http://example.com/private")
+	ut.AssertTrue(parseRobots(strings.NewReader("")).rules("SurfBot").allowed(u))
+	ut.AssertTrue(robotsAllowAll.rules("SurfBot").allowed(u))
+	ut.AssertFalse(robotsDisallowAll.rules("SurfBot").allowed(u))
+}
@@ -0,0 +1,125 @@
+package browser
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timing holds the durations of the phases of a request, as measured when
+// tracing is enabled with SetTracing.
+//
+// Phases which did not happen, such as the DNS lookup and connection of a
+// request reusing a connection, have a zero duration. When a request is
+// redirected or retried, the durations are those of the last request made.
+type Timing struct {
+	// DNS is the duration of the DNS lookup.
+	DNS time.Duration
+
+	// Connect is the duration of establishing the TCP connection.
+	Connect time.Duration
+
+	// TLS is the duration of the TLS handshake.
+	TLS time.Duration
+
+	// FirstByte is the duration between writing the request and reading the
+	// first byte of the response.
+	FirstByte time.Duration
+
+	// Total is the duration between sending the request and reading the
+	// response headers, including any redirects and retries.
+	Total time.Duration
+
+	// Reused is true when the request was sent over a reused connection.
+	Reused bool
+}
+
+// tracer records the timing of requests using a httptrace.ClientTrace.
+//
+// The trace callbacks may be called from other goroutines, so the tracer
+// is safe for concurrent use.
+type tracer struct {
+	mu     sync.Mutex
+	start  time.Time
+	timing Timing
+
+	dnsStart, connectStart, tlsStart, wrote time.Time
+}
+
+// newTracer creates and returns a *tracer type.
+func newTracer() *tracer {
+	return &tracer{start: time.Now()}
+}
+
+// clientTrace returns the trace recording the timing of requests.
+func (t *tracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			t.record(func() {
+				t.timing = Timing{}
+			})
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.record(func() {
+				t.timing.Reused = info.Reused
+			})
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.record(func() {
+				t.dnsStart = time.Now()
+			})
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.record(func() {
+				t.timing.DNS = time.Since(t.dnsStart)
+			})
+		},
+		ConnectStart: func(string, string) {
+			t.record(func() {
+				t.connectStart = time.Now()
+			})
+		},
+		ConnectDone: func(string, string, error) {
+			t.record(func() {
+				t.timing.Connect = time.Since(t.connectStart)
+			})
+		},
+		TLSHandshakeStart: func() {
+			t.record(func() {
+				t.tlsStart = time.Now()
+			})
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.record(func() {
+				t.timing.TLS = time.Since(t.tlsStart)
+			})
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.record(func() {
+				t.wrote = time.Now()
+			})
+		},
+		GotFirstResponseByte: func() {
+			t.record(func() {
+				t.timing.FirstByte = time.Since(t.wrote)
+			})
+		},
+	}
+}
+
+// record calls the given function with the tracer locked.
+func (t *tracer) record(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fn()
+}
+
+// result returns the timing of the last request.
+func (t *tracer) result() Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	timing := t.timing
+	timing.Total = time.Since(t.start)
+	return timing
+}
@@ -0,0 +1,101 @@
+package browser
+
+import (
+	"net/url"
+	"strings"
+)
+
+// NormalizeURL returns the key used by default to decide whether two URLs
+// are the same page when recording visited URLs.
+//
+// The scheme and host are lowercased, the default port of the scheme is
+// removed, an empty path becomes "/", the query parameters are sorted by name,
+// and the fragment is removed. So "HTTP://Example.com:80/?b=2&a=1#top" and
+// "http://example.com/?a=1&b=2" are the same page. Paths are case sensitive,
+// and are not changed.
+func NormalizeURL(u *url.URL) string {
+	n := *u
+	n.Scheme = strings.ToLower(n.Scheme)
+	n.Host = strings.ToLower(n.Host)
+	if port := n.Port(); (n.Scheme == "http" && port == "80") || (n.Scheme == "https" && port == "443") {
+		n.Host = strings.TrimSuffix(n.Host, ":"+port)
+	}
+	if n.Path == "" && n.Opaque == "" && n.Host != "" {
+		n.Path = "/"
+	}
+	if n.RawQuery != "" {
+		n.RawQuery = n.Query().Encode()
+	}
+	n.ForceQuery = false
+	n.Fragment = ""
+	n.RawFragment = ""
+	return n.String()
+}
+
+// visitedKey returns the key of the given URL in the visited URLs.
+func (bow *Browser) visitedKey(u *url.URL) string {
+	if bow.normalizer != nil {
+		return bow.normalizer(u)
+	}
+	return NormalizeURL(u)
+}
+
+// recordVisited adds the given URL to the visited URLs.
+func (bow *Browser) recordVisited(u *url.URL) {
+	if u == nil {
+		return
+	}
+	if bow.visited == nil {
+		bow.visited = make(map[string]*url.URL)
+	}
+	bow.visited[bow.visitedKey(u)] = u
+}
+
+// Visited returns whether the page at the given URL has been loaded by the
+// browser, as the page itself or as a URL redirecting to it, since the browser
+// was created or ResetVisited was called.
+//
+// URLs are compared using the URL normalizer, which is NormalizeURL unless
+// another normalizer is set with SetURLNormalizer. Relative URLs are resolved
+// against the current page. Returns false when the URL cannot be parsed.
+//
+// Crawlers may use Visited to skip the pages they have already seen:
+//
+//	for _, link := range bow.Links() {
+//		if !bow.Visited(link.Url().String()) {
+//			queue = append(queue, link.Url().String())
+//		}
+//	}
+func (bow *Browser) Visited(u string) bool {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	if !pu.IsAbs() && bow.state != nil && bow.state.Request != nil {
+		pu = bow.ResolveUrl(pu)
+	}
+	_, ok := bow.visited[bow.visitedKey(pu)]
+	return ok
+}
+
+// ResetVisited forgets the visited URLs.
+func (bow *Browser) ResetVisited() {
+	bow.visited = nil
+}
+
+// SetURLNormalizer sets the function returning the key used to decide whether
+// two URLs are the same page when recording visited URLs.
+//
+// URLs with the same key are the same page. A crawl treating "www.example.com"
+// and "example.com" as the same site could, for instance, strip the "www."
+// prefix from the host before calling NormalizeURL. The URLs already visited
+// are compared using the new normalizer. A nil function restores
+// NormalizeURL.
+func (bow *Browser) SetURLNormalizer(fn func(*url.URL) string) {
+	bow.normalizer = fn
+	visited := bow.visited
+	bow.visited = nil
+	for _, u := range visited {
+		bow.recordVisited(u)
+	}
+}
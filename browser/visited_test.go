@@ -0,0 +1,28 @@
+package browser
+
+import (
+	"github.com/headzoo/ut"
+	"net/url"
+	"testing"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	ut.Run(t)
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{"HTTP://Example.COM:80/?b=2&a=1#top", "http://example.com/?a=1&b=2"},
+		{"https://example.com:443", "https://example.com/"},
+		{"https://example.com:8443/Path", "https://example.com:8443/Path"},
+		{"http://example.com/page?", "http://example.com/page"},
+		{"http://example.com/search?q=a+b&q=c", "http://example.com/search?q=a+b&q=c"},
+		{"http://example.com/search?q=a%20b", "http://example.com/search?q=a+b"},
+		{"data:text/plain,hi", "data:text/plain,hi"},
+	}
+	for _, test := range tests {
+		u, err := url.Parse(test.in)
+		ut.AssertNil(err)
+		ut.AssertEquals(test.out, NormalizeURL(u))
+	}
+}
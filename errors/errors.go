@@ -110,3 +110,87 @@ func NewInvalidFormValue(msg string, a ...interface{}) InvalidFormValue {
 		error: errors.New(msg),
 	}
 }
+
+// Timeout represents a request that did not complete within the time allowed.
+type Timeout struct {
+	error
+}
+
+// NewTimeout creates and returns a Timeout type.
+func NewTimeout(msg string, a ...interface{}) Timeout {
+	msg = fmt.Sprintf("Timeout: "+msg, a...)
+	return Timeout{
+		error: errors.New(msg),
+	}
+}
+
+// Retry represents a request that still failed after being retried.
+type Retry struct {
+	error
+
+	// last is the error returned by the final attempt.
+	last error
+}
+
+// NewRetry creates and returns a Retry type wrapping the error returned by
+// the final attempt.
+func NewRetry(last error, msg string, a ...interface{}) Retry {
+	msg = fmt.Sprintf("Retry: "+msg, a...)
+	return Retry{
+		error: errors.New(msg + " " + last.Error()),
+		last:  last,
+	}
+}
+
+// Unwrap returns the error returned by the final attempt.
+func (e Retry) Unwrap() error {
+	return e.last
+}
+
+// BodyTooLarge represents a response with a body larger than allowed.
+type BodyTooLarge struct {
+	error
+}
+
+// NewBodyTooLarge creates and returns a BodyTooLarge type.
+func NewBodyTooLarge(msg string, a ...interface{}) BodyTooLarge {
+	msg = fmt.Sprintf("Body Too Large: "+msg, a...)
+	return BodyTooLarge{
+		error: errors.New(msg),
+	}
+}
+
+// HTTPError represents a page loaded with a status code indicating failure.
+type HTTPError struct {
+	error
+
+	// StatusCode is the status code of the response.
+	StatusCode int
+
+	// Body is the body of the response, such as the text of an error page.
+	Body []byte
+}
+
+// NewHTTPError creates and returns a HTTPError type.
+func NewHTTPError(statusCode int, body []byte, msg string, a ...interface{}) HTTPError {
+	msg = fmt.Sprintf("HTTP Error: "+msg, a...)
+	return HTTPError{
+		error:      errors.New(msg),
+		StatusCode: statusCode,
+		Body:       body,
+	}
+}
+
+// RobotsDisallowed represents a request disallowed by the robots.txt file of
+// the site.
+type RobotsDisallowed struct {
+	error
+}
+
+// NewRobotsDisallowed creates and returns a RobotsDisallowed type.
+func NewRobotsDisallowed(msg string, a ...interface{}) RobotsDisallowed {
+	msg = fmt.Sprintf("Robots Disallowed: "+msg, a...)
+	return RobotsDisallowed{
+		error: errors.New(msg),
+	}
+}
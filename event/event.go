@@ -0,0 +1,226 @@
+// Package event contains a dispatcher used to notify listeners of browser events.
+package event
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Event identifies something happening in a browser that listeners may be
+// notified of.
+type Event string
+
+const (
+	// PreRequest is dispatched with the *http.Request of each request before
+	// it is sent. Listeners may modify the request, for instance to add
+	// headers or sign the request, or return an error to abort the request.
+	PreRequest Event = "PreRequest"
+
+	// PostResponse is dispatched with a *Response after each response is
+	// received, and before the response is parsed. The body of the response
+	// has been read, and may be read again by listeners. An error returned by
+	// a listener aborts the navigation, leaving the current page unchanged.
+	PostResponse Event = "PostResponse"
+
+	// Submit is dispatched with a *Submission before a form is submitted.
+	// Listeners may return an error to abort the submission.
+	Submit Event = "Submit"
+
+	// Warn is dispatched with a *Warning when the browser works around a
+	// problem in a page, such as a form with an unknown method. Errors
+	// returned by listeners are ignored.
+	Warn Event = "Warn"
+
+	// NavigateStart is dispatched with a *Navigation when the browser starts
+	// requesting a page, such as when opening a URL, following a link,
+	// submitting a form, or reloading the page. Listeners may return an error
+	// to abort the navigation. Back() and Forward() restore pages from the
+	// history without a request, and do not dispatch navigation events.
+	NavigateStart Event = "NavigateStart"
+
+	// NavigateFinish is dispatched with a *NavigationResult when a page has
+	// been loaded. Errors returned by listeners are ignored.
+	NavigateFinish Event = "NavigateFinish"
+
+	// NavigateError is dispatched with a *NavigationError when loading a page
+	// fails, and the browser method which started the navigation returns an
+	// error. Errors returned by listeners are ignored.
+	NavigateError Event = "NavigateError"
+)
+
+// Response is the payload of the PostResponse event.
+type Response struct {
+	// Response is the response received by the browser.
+	Response *http.Response
+
+	// Elapsed is the time between sending the request and reading the body
+	// of the response.
+	Elapsed time.Duration
+}
+
+// Submission is the payload of the Submit event.
+type Submission struct {
+	// Method is the method used to submit the form, either GET or POST.
+	Method string
+
+	// Action is the URL the form is submitted to.
+	Action string
+
+	// Values are the values that will be submitted, including the values of
+	// the clicked button.
+	Values url.Values
+
+	// Button holds the values of the clicked button, keyed by the button
+	// name, or the click coordinates of an image button. Button is nil when
+	// the form is submitted without clicking a button, or when the clicked
+	// button has no name.
+	Button url.Values
+}
+
+// Warning is the payload of the Warn event.
+type Warning struct {
+	// Url is the URL of the page with the problem.
+	Url string
+
+	// Message describes the problem, and how the browser works around it.
+	Message string
+}
+
+// Navigation is the payload of the NavigateStart event.
+type Navigation struct {
+	// Method is the method of the request, such as GET or POST.
+	Method string
+
+	// Url is the URL of the page requested.
+	Url string
+}
+
+// NavigationResult is the payload of the NavigateFinish event.
+type NavigationResult struct {
+	// Method is the method of the request, such as GET or POST.
+	Method string
+
+	// Url is the URL of the page loaded, which is the final URL when the
+	// request was redirected.
+	Url string
+
+	// StatusCode is the status code of the response.
+	StatusCode int
+
+	// Elapsed is the time taken to load the page.
+	Elapsed time.Duration
+}
+
+// NavigationError is the payload of the NavigateError event.
+type NavigationError struct {
+	// Method is the method of the request, such as GET or POST.
+	Method string
+
+	// Url is the URL of the page requested.
+	Url string
+
+	// Err is the error returned by the browser.
+	Err error
+
+	// Elapsed is the time between the start of the navigation and the error.
+	Elapsed time.Duration
+}
+
+// Handler is a function called with the payload of the events it listens to.
+//
+// The type of the payload depends on the event, and is documented with each
+// event.
+//
+// Handlers may return an error to abort the operation that dispatched the
+// event. The error is returned by Dispatcher.Do, and by the browser method
+// which dispatched the event.
+type Handler func(payload interface{}) error
+
+// ListenerID identifies a handler added to a Dispatcher, and is used to
+// remove the handler.
+type ListenerID uint64
+
+// listener is a handler added to a Dispatcher.
+type listener struct {
+	id      ListenerID
+	handler Handler
+	once    bool
+}
+
+// Dispatcher calls the handlers listening to an event when the event is
+// dispatched.
+//
+// The zero value of a Dispatcher has no listeners and is ready to use.
+type Dispatcher struct {
+	listeners map[Event][]*listener
+	lastID    ListenerID
+}
+
+// NewDispatcher creates and returns a new *Dispatcher type.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{}
+}
+
+// On adds a handler listening to the given event, and returns the ID used to
+// remove the handler.
+func (d *Dispatcher) On(e Event, h Handler) ListenerID {
+	return d.add(e, h, false)
+}
+
+// Once adds a handler listening to the given event, which is removed after
+// it has been called once.
+func (d *Dispatcher) Once(e Event, h Handler) ListenerID {
+	return d.add(e, h, true)
+}
+
+// Off removes the handler with the given ID from the given event, and
+// returns whether the handler was removed.
+func (d *Dispatcher) Off(e Event, id ListenerID) bool {
+	for i, l := range d.listeners[e] {
+		if l.id == id {
+			d.remove(e, i)
+			return true
+		}
+	}
+	return false
+}
+
+// add adds a handler listening to the given event.
+func (d *Dispatcher) add(e Event, h Handler, once bool) ListenerID {
+	if d.listeners == nil {
+		d.listeners = make(map[Event][]*listener)
+	}
+	d.lastID++
+	d.listeners[e] = append(d.listeners[e], &listener{id: d.lastID, handler: h, once: once})
+	return d.lastID
+}
+
+// remove removes the listener at the given index from the given event.
+func (d *Dispatcher) remove(e Event, i int) {
+	listeners := d.listeners[e]
+	d.listeners[e] = append(listeners[:i:i], listeners[i+1:]...)
+	if len(d.listeners[e]) == 0 {
+		delete(d.listeners, e)
+	}
+}
+
+// Do dispatches the given event, calling each handler listening to the event
+// with the payload, in the order the handlers were added.
+//
+// The first handler returning an error stops the dispatch, and the error is
+// returned without calling the remaining handlers. Handlers added with Once
+// are removed before they are called.
+func (d *Dispatcher) Do(e Event, payload interface{}) error {
+	// Handlers may add or remove handlers, so the dispatch works on a copy.
+	listeners := append([]*listener(nil), d.listeners[e]...)
+	for _, l := range listeners {
+		if l.once && !d.Off(e, l.id) {
+			continue
+		}
+		if err := l.handler(payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package event
+
+import (
+	"errors"
+	"github.com/headzoo/ut"
+	"testing"
+)
+
+func TestDispatcher(t *testing.T) {
+	ut.Run(t)
+
+	calls := []string{}
+	d := NewDispatcher()
+	ut.AssertNil(d.Do(PreRequest, "ignored"))
+	d.On(PreRequest, func(payload interface{}) error {
+		calls = append(calls, "first:"+payload.(string))
+		return nil
+	})
+	d.On(PreRequest, func(payload interface{}) error {
+		calls = append(calls, "second:"+payload.(string))
+		return nil
+	})
+	d.On(Event("Other"), func(payload interface{}) error {
+		calls = append(calls, "other")
+		return nil
+	})
+
+	ut.AssertNil(d.Do(PreRequest, "request"))
+	ut.AssertEquals([]string{"first:request", "second:request"}, calls)
+}
+
+func TestDispatcherError(t *testing.T) {
+	ut.Run(t)
+
+	calls := 0
+	d := NewDispatcher()
+	d.On(Submit, func(payload interface{}) error {
+		calls++
+		return nil
+	})
+	d.On(Submit, func(payload interface{}) error {
+		calls++
+		return errors.New("first")
+	})
+	d.On(Submit, func(payload interface{}) error {
+		calls++
+		return errors.New("second")
+	})
+
+	err := d.Do(Submit, nil)
+	ut.AssertNotNil(err)
+	ut.AssertEquals("first", err.Error())
+	ut.AssertEquals(2, calls)
+}
+
+func TestDispatcherOff(t *testing.T) {
+	ut.Run(t)
+
+	calls := []string{}
+	d := NewDispatcher()
+	first := d.On(PreRequest, func(payload interface{}) error {
+		calls = append(calls, "first")
+		return nil
+	})
+	d.On(PreRequest, func(payload interface{}) error {
+		calls = append(calls, "second")
+		return nil
+	})
+
+	ut.AssertFalse(d.Off(PostResponse, first))
+	ut.AssertTrue(d.Off(PreRequest, first))
+	ut.AssertFalse(d.Off(PreRequest, first))
+	d.Do(PreRequest, nil)
+	ut.AssertEquals([]string{"second"}, calls)
+}
+
+func TestDispatcherOnce(t *testing.T) {
+	ut.Run(t)
+
+	calls := 0
+	d := NewDispatcher()
+	id := d.Once(Submit, func(payload interface{}) error {
+		calls++
+		// Events dispatched by a handler do not call it again.
+		return d.Do(Submit, nil)
+	})
+
+	ut.AssertNil(d.Do(Submit, nil))
+	ut.AssertNil(d.Do(Submit, nil))
+	ut.AssertEquals(1, calls)
+	ut.AssertFalse(d.Off(Submit, id))
+}
@@ -0,0 +1,60 @@
+package jar
+
+import (
+	"net/http"
+	"sync"
+)
+
+// Cache is a container for storage and retrieval of response bodies.
+type Cache interface {
+	// Get returns the body and headers stored with the given key, and whether
+	// an entry was found.
+	Get(key string) ([]byte, http.Header, bool)
+
+	// Set stores the body and headers with the given key.
+	Set(key string, body []byte, headers http.Header)
+}
+
+// cacheEntry is a response stored in a MemoryCache.
+type cacheEntry struct {
+	body    []byte
+	headers http.Header
+}
+
+// MemoryCache is an in-memory implementation of the Cache interface.
+//
+// A MemoryCache is safe for concurrent use.
+type MemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]*cacheEntry
+}
+
+// NewMemoryCache creates and returns a new *MemoryCache type.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		entries: make(map[string]*cacheEntry),
+	}
+}
+
+// Get returns the body and headers stored with the given key, and whether an
+// entry was found.
+func (c *MemoryCache) Get(key string) ([]byte, http.Header, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, nil, false
+	}
+	return entry.body, entry.headers.Clone(), true
+}
+
+// Set stores the body and headers with the given key, replacing any entry
+// already stored with the key.
+func (c *MemoryCache) Set(key string, body []byte, headers http.Header) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = &cacheEntry{
+		body:    body,
+		headers: headers.Clone(),
+	}
+}
@@ -0,0 +1,28 @@
+package jar
+
+import (
+	"github.com/headzoo/ut"
+	"net/http"
+	"testing"
+)
+
+func TestMemoryCache(t *testing.T) {
+	ut.Run(t)
+
+	c := NewMemoryCache()
+	_, _, ok := c.Get("http://localhost")
+	ut.AssertFalse(ok)
+
+	headers := http.Header{"Etag": {"v1"}}
+	c.Set("http://localhost", []byte("body"), headers)
+	headers.Set("Etag", "changed")
+	body, h, ok := c.Get("http://localhost")
+	ut.AssertTrue(ok)
+	ut.AssertEquals("body", string(body))
+	ut.AssertEquals("v1", h.Get("Etag"))
+
+	c.Set("http://localhost", []byte("new"), nil)
+	body, _, ok = c.Get("http://localhost")
+	ut.AssertTrue(ok)
+	ut.AssertEquals("new", string(body))
+}
@@ -1,11 +1,20 @@
 package jar
 
-import "net/http/cookiejar"
+import (
+	"golang.org/x/net/publicsuffix"
+	"net/http/cookiejar"
+)
 
 // New returns a new cookie jar.
+//
+// The jar uses the public suffix list, so a cookie set for a domain such as
+// "co.uk" is rejected, and the cookies of "a.co.uk" are never sent to
+// "b.co.uk".
 func NewMemoryCookies() *cookiejar.Jar {
 	// cookiejar.New returns an error, but it's always nil. Maybe it's there
 	// for future use or to conform to an interface?
-	jar, _ := cookiejar.New(nil)
+	jar, _ := cookiejar.New(&cookiejar.Options{
+		PublicSuffixList: publicsuffix.List,
+	})
 	return jar
 }
@@ -0,0 +1,28 @@
+package jar
+
+import (
+	"github.com/headzoo/ut"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestMemoryCookiesPublicSuffix(t *testing.T) {
+	ut.Run(t)
+
+	j := NewMemoryCookies()
+	a, _ := url.Parse("http://a.co.uk/")
+	b, _ := url.Parse("http://b.co.uk/")
+	www, _ := url.Parse("http://www.a.co.uk/")
+	j.SetCookies(a, []*http.Cookie{
+		{Name: "suffix", Value: "1", Domain: "co.uk"},
+		{Name: "domain", Value: "2", Domain: "a.co.uk"},
+		{Name: "host", Value: "3"},
+	})
+
+	ut.AssertEquals(0, len(j.Cookies(b)))
+	ut.AssertEquals(2, len(j.Cookies(a)))
+	cookies := j.Cookies(www)
+	ut.AssertEquals(1, len(cookies))
+	ut.AssertEquals("domain", cookies[0].Name)
+}
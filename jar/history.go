@@ -7,9 +7,11 @@ import (
 
 // State represents a point in time.
 type State struct {
-	Request  *http.Request
-	Response *http.Response
-	Dom      *goquery.Document
+	Request   *http.Request
+	Response  *http.Response
+	Dom       *goquery.Document
+	Body      []byte
+	FromCache bool
 }
 
 // NewHistoryState creates and returns a new *State type.
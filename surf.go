@@ -19,6 +19,21 @@ var (
 
 	// DefaultFollowRedirectsAttribute is the global value for the AttributeFollowRedirects attribute.
 	DefaultFollowRedirects = true
+
+	// DefaultMaxRedirects is the global maximum number of redirects followed for each request.
+	DefaultMaxRedirects = 10
+
+	// DefaultRetryPostAttribute is the global value for the RetryPost attribute.
+	DefaultRetryPost = false
+
+	// DefaultRandomUserAgentAttribute is the global value for the RandomUserAgent attribute.
+	DefaultRandomUserAgent = false
+
+	// DefaultReloadPostAttribute is the global value for the ReloadPost attribute.
+	DefaultReloadPost = true
+
+	// DefaultHTTPErrorsAttribute is the global value for the HTTPErrors attribute.
+	DefaultHTTPErrors = false
 )
 
 // NewBrowser creates and returns a *browser.Browser type.
@@ -29,10 +44,15 @@ func NewBrowser() *browser.Browser {
 	bow.SetBookmarksJar(jar.NewMemoryBookmarks())
 	bow.SetHistoryJar(jar.NewMemoryHistory())
 	bow.SetHeadersJar(jar.NewMemoryHeaders())
+	bow.SetMaxRedirects(DefaultMaxRedirects)
 	bow.SetAttributes(browser.AttributeMap{
 		browser.SendReferer:         DefaultSendReferer,
 		browser.MetaRefreshHandling: DefaultMetaRefreshHandling,
 		browser.FollowRedirects:     DefaultFollowRedirects,
+		browser.RetryPost:           DefaultRetryPost,
+		browser.RandomUserAgent:     DefaultRandomUserAgent,
+		browser.ReloadPost:          DefaultReloadPost,
+		browser.HTTPErrors:          DefaultHTTPErrors,
 	})
 
 	return bow
@@ -2,13 +2,32 @@ package surf
 
 import (
 	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"fmt"
 	"github.com/headzoo/surf/browser"
+	"github.com/headzoo/surf/errors"
+	"github.com/headzoo/surf/event"
 	"github.com/headzoo/surf/jar"
 	"github.com/headzoo/ut"
+	"golang.org/x/text/encoding/japanese"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestGet(t *testing.T) {
@@ -42,6 +61,1506 @@ func TestGet(t *testing.T) {
 	ut.AssertEquals("Surf Page 1", bow.Title())
 }
 
+func TestForward(t *testing.T) {
+	ut.Run(t)
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if req.URL.Path == "/page1" {
+			fmt.Fprint(w, htmlPage1)
+		} else {
+			fmt.Fprint(w, htmlPage2)
+		}
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	ut.AssertEquals(0, bow.HistoryLength())
+	ut.AssertNil(bow.Open(ts.URL + "/page1"))
+	ut.AssertNil(bow.Open(ts.URL + "/page2"))
+	ut.AssertEquals(2, bow.HistoryLength())
+	ut.AssertFalse(bow.Forward())
+
+	ut.AssertTrue(bow.Back())
+	ut.AssertEquals("Surf Page 1", bow.Title())
+	ut.AssertEquals(2, bow.HistoryLength())
+	ut.AssertTrue(bow.Forward())
+	ut.AssertEquals("Surf Page 2", bow.Title())
+	ut.AssertEquals(ts.URL+"/page2", bow.Url().String())
+	ut.AssertFalse(bow.Forward())
+	ut.AssertEquals(int32(2), atomic.LoadInt32(&hits))
+
+	ut.AssertTrue(bow.Back())
+	ut.AssertNil(bow.Open(ts.URL + "/page3"))
+	ut.AssertFalse(bow.Forward())
+	ut.AssertEquals(2, bow.HistoryLength())
+}
+
+func TestReload(t *testing.T) {
+	ut.Run(t)
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		body, _ := ioutil.ReadAll(req.Body)
+		fmt.Fprintf(w, "%d %s %s %s", n, req.Method, req.URL.Path, body)
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	err := bow.Open(ts.URL + "/page")
+	ut.AssertNil(err)
+	err = bow.Reload()
+	ut.AssertNil(err)
+	ut.AssertEquals("2 GET /page ", bow.Body())
+
+	err = bow.Post(ts.URL+"/form", "text/plain", strings.NewReader("name=surf"))
+	ut.AssertNil(err)
+	err = bow.Reload()
+	ut.AssertNil(err)
+	ut.AssertEquals("4 POST /form name=surf", bow.Body())
+
+	bow.SetAttribute(browser.ReloadPost, false)
+	err = bow.Reload()
+	ut.AssertNotNil(err)
+	ut.AssertEquals(int32(4), atomic.LoadInt32(&hits))
+}
+
+func TestOpenWithContext(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := bow.OpenWithContext(ctx, ts.URL)
+	ut.AssertEquals(context.DeadlineExceeded, err)
+	ut.AssertTrue(time.Since(start) < 2*time.Second)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	err = bow.PostWithContext(ctx, ts.URL, "text/plain", strings.NewReader("surf"))
+	ut.AssertEquals(context.Canceled, err)
+}
+
+func TestTimeout(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/slow" {
+			select {
+			case <-req.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}
+		fmt.Fprint(w, htmlPage1)
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	bow.SetTimeout(50 * time.Millisecond)
+	err := bow.Open(ts.URL + "/slow")
+	_, ok := err.(errors.Timeout)
+	ut.AssertTrue(ok)
+
+	err = bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals("Surf Page 1", bow.Title())
+}
+
+func TestRetryPolicy(t *testing.T) {
+	ut.Run(t)
+	failures := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, htmlPage1)
+	}))
+	defer ts.Close()
+
+	attempts := []int{}
+	bow := NewBrowser()
+	bow.SetRetryPolicy(2, func(attempt int) time.Duration {
+		attempts = append(attempts, attempt)
+		return time.Millisecond
+	})
+
+	failures = 2
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals(200, bow.StatusCode())
+	ut.AssertEquals([]int{1, 2}, attempts)
+
+	failures = 3
+	err = bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals(503, bow.StatusCode())
+
+	failures = 1
+	err = bow.Post(ts.URL, "text/plain", strings.NewReader("surf"))
+	ut.AssertNil(err)
+	ut.AssertEquals(503, bow.StatusCode())
+
+	failures = 1
+	bow.SetAttribute(browser.RetryPost, true)
+	err = bow.Post(ts.URL, "text/plain", strings.NewReader("surf"))
+	ut.AssertNil(err)
+	ut.AssertEquals(200, bow.StatusCode())
+
+	ts.Close()
+	err = bow.Open(ts.URL)
+	rerr, ok := err.(errors.Retry)
+	ut.AssertTrue(ok)
+	ut.AssertNotNil(rerr.Unwrap())
+}
+
+func TestRateLimit(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, htmlPage1)
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	bow.SetRateLimit(2, 200*time.Millisecond)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		ut.AssertNil(bow.Open(ts.URL))
+	}
+	ut.AssertTrue(time.Since(start) >= 200*time.Millisecond)
+
+	bow.SetRateLimit(1, time.Minute)
+	ut.AssertNil(bow.Open(ts.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := bow.OpenWithContext(ctx, ts.URL)
+	ut.AssertEquals(context.DeadlineExceeded, err)
+
+	bow.SetRateLimit(0, 0)
+	start = time.Now()
+	for i := 0; i < 3; i++ {
+		ut.AssertNil(bow.Open(ts.URL))
+	}
+	ut.AssertTrue(time.Since(start) < 200*time.Millisecond)
+}
+
+func TestCompression(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Accept-Encoding") == "" {
+			fmt.Fprint(w, htmlPage2)
+			return
+		}
+		buff := &bytes.Buffer{}
+		var zw io.WriteCloser
+		if req.URL.Path == "/deflate" {
+			w.Header().Set("Content-Encoding", "deflate")
+			zw = zlib.NewWriter(buff)
+		} else {
+			w.Header().Set("Content-Encoding", "gzip")
+			zw = gzip.NewWriter(buff)
+		}
+		io.WriteString(zw, htmlPage1)
+		zw.Close()
+		w.Write(buff.Bytes())
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals("Surf Page 1", bow.Title())
+
+	err = bow.Open(ts.URL + "/deflate")
+	ut.AssertNil(err)
+	ut.AssertEquals("Surf Page 1", bow.Title())
+
+	bow.SetCompression(false)
+	err = bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals("Surf Page 2", bow.Title())
+}
+
+func TestProxy(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Proxied-Url", req.URL.String())
+		w.Header().Set("X-Proxy-Auth", req.Header.Get("Proxy-Authorization"))
+		fmt.Fprint(w, htmlPage1)
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	ut.AssertNotNil(bow.SetProxy("ftp://proxy.example"))
+	ut.AssertNotNil(bow.SetProxy("http://"))
+
+	proxy := strings.Replace(ts.URL, "http://", "http://user:pass@", 1)
+	err := bow.SetProxy(proxy)
+	ut.AssertNil(err)
+	err = bow.Open("http://surf.example/page")
+	ut.AssertNil(err)
+	ut.AssertEquals("Surf Page 1", bow.Title())
+	ut.AssertEquals("http://surf.example/page", bow.ResponseHeaders().Get("X-Proxied-Url"))
+	ut.AssertEquals("Basic dXNlcjpwYXNz", bow.ResponseHeaders().Get("X-Proxy-Auth"))
+
+	proxied := []string{}
+	bow.SetProxyFunc(func(req *http.Request) (*url.URL, error) {
+		proxied = append(proxied, req.URL.Host)
+		return url.Parse(ts.URL)
+	})
+	err = bow.Open("http://other.example/")
+	ut.AssertNil(err)
+	ut.AssertEquals([]string{"other.example"}, proxied)
+}
+
+func TestClone(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret"})
+		}
+		session := ""
+		if c, err := req.Cookie("session"); err == nil {
+			session = c.Value
+		}
+		fmt.Fprintf(w, "%s|%s|%s", session, req.Header.Get("X-Worker"), req.UserAgent())
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	bow.SetUserAgent("SurfWorker/1.0")
+	bow.AddRequestHeader("X-Worker", "pool")
+	err := bow.Open(ts.URL + "/login")
+	ut.AssertNil(err)
+
+	clone := bow.Clone()
+	ut.AssertEquals(0, clone.HistoryLength())
+	err = clone.Open(ts.URL + "/page")
+	ut.AssertNil(err)
+	ut.AssertEquals("|pool|SurfWorker/1.0", clone.Body())
+
+	err = bow.Open(ts.URL + "/page")
+	ut.AssertNil(err)
+	ut.AssertEquals("secret|pool|SurfWorker/1.0", bow.Body())
+
+	proxied := 0
+	clone.SetProxyFunc(func(req *http.Request) (*url.URL, error) {
+		proxied++
+		return nil, nil
+	})
+	err = bow.Open(ts.URL + "/page")
+	ut.AssertNil(err)
+	ut.AssertEquals("secret|pool|SurfWorker/1.0", bow.Body())
+	ut.AssertEquals(0, proxied)
+	err = clone.Open(ts.URL + "/page")
+	ut.AssertNil(err)
+	ut.AssertEquals(1, proxied)
+}
+
+func TestVisited(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/old":
+			http.Redirect(w, req, "/new", http.StatusFound)
+		case "/WWW":
+			fmt.Fprint(w, htmlPage2)
+		default:
+			fmt.Fprint(w, htmlPage1)
+		}
+	}))
+	defer ts.Close()
+	host := strings.TrimPrefix(ts.URL, "http://")
+
+	bow := NewBrowser()
+	ut.AssertFalse(bow.Visited(ts.URL))
+	err := bow.Open(ts.URL + "/page?b=2&a=1")
+	ut.AssertNil(err)
+	ut.AssertTrue(bow.Visited(ts.URL + "/page?a=1&b=2#top"))
+	ut.AssertTrue(bow.Visited("HTTP://" + strings.ToUpper(host) + "/page?b=2&a=1"))
+	ut.AssertTrue(bow.Visited("/page?a=1&b=2"))
+	ut.AssertFalse(bow.Visited(ts.URL + "/page"))
+	ut.AssertFalse(bow.Visited(ts.URL + "/PAGE?a=1&b=2"))
+	ut.AssertFalse(bow.Visited("%zz"))
+
+	err = bow.Open(ts.URL + "/old")
+	ut.AssertNil(err)
+	ut.AssertTrue(bow.Visited(ts.URL + "/old"))
+	ut.AssertTrue(bow.Visited(ts.URL + "/new"))
+
+	results := bow.OpenAll([]string{ts.URL + "/a", ts.URL + "/b"}, 2)
+	ut.AssertNil(results[0].Error)
+	ut.AssertTrue(bow.Visited(ts.URL + "/a"))
+	ut.AssertTrue(bow.Visited(ts.URL + "/b"))
+
+	bow.SetURLNormalizer(func(u *url.URL) string {
+		return strings.ToLower(browser.NormalizeURL(u))
+	})
+	ut.AssertTrue(bow.Visited(ts.URL + "/PAGE?a=1&b=2"))
+	ut.AssertFalse(bow.Visited(ts.URL + "/www"))
+	err = bow.Open(ts.URL + "/WWW")
+	ut.AssertNil(err)
+	ut.AssertTrue(bow.Visited(ts.URL + "/www"))
+
+	bow.ResetVisited()
+	ut.AssertFalse(bow.Visited(ts.URL + "/WWW"))
+	ut.AssertFalse(bow.Visited(ts.URL + "/old"))
+	bow.SetURLNormalizer(nil)
+	err = bow.Open(ts.URL + "/WWW")
+	ut.AssertNil(err)
+	ut.AssertFalse(bow.Visited(ts.URL + "/www"))
+}
+
+func TestRespectRobots(t *testing.T) {
+	ut.Run(t)
+	var robotsHits, privateHits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/robots.txt":
+			atomic.AddInt32(&robotsHits, 1)
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private\nAllow: /private/public\nCrawl-delay: 0.2\n\nUser-agent: SurfBot\nDisallow: /\n")
+		case req.URL.Path == "/go":
+			http.Redirect(w, req, "/private", http.StatusFound)
+		case strings.HasPrefix(req.URL.Path, "/private"):
+			atomic.AddInt32(&privateHits, 1)
+			fmt.Fprint(w, htmlPage1)
+		default:
+			fmt.Fprint(w, htmlPage1)
+		}
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	bow.SetUserAgent("TestAgent")
+	bow.SetRespectRobots(true)
+	err := bow.Open(ts.URL + "/page")
+	ut.AssertNil(err)
+	start := time.Now()
+	err = bow.Open(ts.URL + "/private/public")
+	ut.AssertNil(err)
+	ut.AssertTrue(time.Since(start) >= 150*time.Millisecond)
+
+	err = bow.Open(ts.URL + "/private")
+	ut.AssertNotNil(err)
+	_, ok := err.(errors.RobotsDisallowed)
+	ut.AssertTrue(ok)
+	err = bow.Open(ts.URL + "/go")
+	ut.AssertNotNil(err)
+	_, ok = err.(errors.RobotsDisallowed)
+	ut.AssertTrue(ok)
+	ut.AssertEquals(ts.URL+"/private/public", bow.Url().String())
+	ut.AssertEquals(int32(1), atomic.LoadInt32(&privateHits))
+	ut.AssertEquals(int32(1), atomic.LoadInt32(&robotsHits))
+
+	clone := bow.Clone()
+	clone.SetUserAgent("SurfBot/1.0")
+	err = clone.Open(ts.URL + "/page")
+	ut.AssertNotNil(err)
+	ut.AssertEquals(int32(1), atomic.LoadInt32(&robotsHits))
+
+	other := NewBrowser()
+	other.SetUserAgent("SurfBot/1.0")
+	other.SetRespectRobots(true)
+	err = other.Open(ts.URL + "/page")
+	ut.AssertNotNil(err)
+	ut.AssertEquals(int32(2), atomic.LoadInt32(&robotsHits))
+
+	bow.SetRespectRobots(false)
+	err = bow.Open(ts.URL + "/private")
+	ut.AssertNil(err)
+	ut.AssertEquals(int32(2), atomic.LoadInt32(&privateHits))
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, htmlPage1)
+	}))
+	defer down.Close()
+	bow.SetRespectRobots(true)
+	err = bow.Open(down.URL)
+	ut.AssertNotNil(err)
+	ut.AssertContains("Robots Disallowed", err.Error())
+}
+
+func TestOpenAll(t *testing.T) {
+	ut.Run(t)
+	var active, peak int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		if req.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		if req.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret"})
+		}
+		if req.URL.Path == "/visit" {
+			http.SetCookie(w, &http.Cookie{Name: "visited", Value: "yes"})
+		}
+		session := ""
+		if c, err := req.Cookie("session"); err == nil {
+			session = c.Value
+		}
+		fmt.Fprintf(w, "%s %s", req.URL.Path, session)
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	err := bow.Open(ts.URL + "/login")
+	ut.AssertNil(err)
+	urls := []string{ts.URL + "/a", ts.URL + "/b", "http://[::1", ts.URL + "/missing", ts.URL + "/visit", ts.URL + "/c"}
+	results := bow.OpenAll(urls, 3)
+
+	ut.AssertEquals(len(urls), len(results))
+	for i, result := range results {
+		ut.AssertEquals(urls[i], result.Url)
+	}
+	ut.AssertEquals("/a secret", string(results[0].Body))
+	ut.AssertEquals(200, results[1].StatusCode)
+	ut.AssertNotNil(results[2].Error)
+	ut.AssertEquals(0, results[2].StatusCode)
+	ut.AssertEquals(404, results[3].StatusCode)
+	ut.AssertNil(results[3].Error)
+	ut.AssertEquals("/c secret", string(results[5].Body))
+	ut.AssertTrue(atomic.LoadInt32(&peak) > 1)
+	ut.AssertTrue(atomic.LoadInt32(&peak) <= 3)
+
+	ut.AssertEquals("/login ", bow.Body())
+	u, _ := url.Parse(ts.URL)
+	ut.AssertEquals(2, len(bow.Cookies(u)))
+	buf := &bytes.Buffer{}
+	ut.AssertNil(bow.SaveCookies(buf))
+	ut.AssertContains("visited", buf.String())
+}
+
+func TestTLSConfig(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, htmlPage1)
+	}))
+	// The rejected handshakes are expected.
+	ts.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	ts.StartTLS()
+	defer ts.Close()
+
+	bow := NewBrowser()
+	err := bow.Open(ts.URL)
+	ut.AssertNotNil(err)
+
+	bow.SetInsecureSkipVerify(true)
+	err = bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals("Surf Page 1", bow.Title())
+	bow.SetInsecureSkipVerify(false)
+	err = bow.Open(ts.URL)
+	ut.AssertNotNil(err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ts.Certificate())
+	bow.SetTLSConfig(&tls.Config{RootCAs: roots})
+	err = bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals("Surf Page 1", bow.Title())
+}
+
+func TestHTTP2(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, req.Proto)
+	}))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+
+	bow := NewBrowser()
+	bow.SetInsecureSkipVerify(true)
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals("HTTP/2.0", bow.Body())
+
+	bow.SetHTTP2(false)
+	err = bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals("HTTP/1.1", bow.Body())
+	bow.SetInsecureSkipVerify(true)
+	err = bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals("HTTP/1.1", bow.Body())
+
+	bow.SetHTTP2(true)
+	err = bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals("HTTP/2.0", bow.Body())
+}
+
+func TestTracing(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprint(w, htmlPage1)
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	bow.SetInsecureSkipVerify(true)
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals(browser.Timing{}, bow.LastTiming())
+
+	bow.SetTracing(true)
+	err = bow.Open(ts.URL + "/page")
+	ut.AssertNil(err)
+	timing := bow.LastTiming()
+	ut.AssertTrue(timing.Reused)
+	ut.AssertTrue(timing.FirstByte >= 20*time.Millisecond)
+	ut.AssertTrue(timing.Total >= timing.FirstByte)
+	ut.AssertEquals(time.Duration(0), timing.TLS)
+
+	// Rebuilding the transport forces a new connection.
+	bow.SetInsecureSkipVerify(true)
+	err = bow.Open(ts.URL)
+	ut.AssertNil(err)
+	timing = bow.LastTiming()
+	ut.AssertFalse(timing.Reused)
+	ut.AssertTrue(timing.Connect > 0)
+	ut.AssertTrue(timing.TLS > 0)
+}
+
+func TestTransportConfig(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, htmlPage1)
+	}))
+	defer ts.Close()
+
+	proxied := 0
+	bow := NewBrowser()
+	bow.SetTracing(true)
+	bow.SetProxyFunc(func(req *http.Request) (*url.URL, error) {
+		proxied++
+		return nil, nil
+	})
+	ut.AssertNil(bow.Open(ts.URL))
+	ut.AssertNil(bow.Open(ts.URL))
+	ut.AssertTrue(bow.LastTiming().Reused)
+
+	bow.SetTransportConfig(10, 2, 50*time.Millisecond)
+	ut.AssertNil(bow.Open(ts.URL))
+	ut.AssertFalse(bow.LastTiming().Reused)
+	ut.AssertNil(bow.Open(ts.URL))
+	ut.AssertTrue(bow.LastTiming().Reused)
+	ut.AssertEquals(4, proxied)
+
+	time.Sleep(150 * time.Millisecond)
+	ut.AssertNil(bow.Open(ts.URL))
+	ut.AssertFalse(bow.LastTiming().Reused)
+}
+
+func TestURLRewriter(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/old":
+			http.Redirect(w, req, "http://surf.example/new", http.StatusFound)
+		case "/form":
+			fmt.Fprint(w, `<form method="post" action="http://surf.example/submit"><input name="q" value="surf"></form>`)
+		default:
+			req.ParseForm()
+			fmt.Fprintf(w, "%s %s %s %s", req.Method, req.Host, req.URL.Path, req.Form.Encode())
+		}
+	}))
+	defer ts.Close()
+	mock, _ := url.Parse(ts.URL)
+
+	rewritten := []string{}
+	bow := NewBrowser()
+	bow.SetURLRewriter(func(u *url.URL) *url.URL {
+		rewritten = append(rewritten, u.String())
+		if u.Host != "surf.example" {
+			return u
+		}
+		u.Scheme = mock.Scheme
+		u.Host = mock.Host
+		return u
+	})
+
+	err := bow.Open("http://surf.example/old")
+	ut.AssertNil(err)
+	ut.AssertEquals("GET "+mock.Host+" /new ", bow.Body())
+	ut.AssertEquals([]string{"http://surf.example/old", "http://surf.example/new"}, rewritten)
+
+	err = bow.Open(ts.URL + "/form")
+	ut.AssertNil(err)
+	f, err := bow.Form("form")
+	ut.AssertNil(err)
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertEquals("POST "+mock.Host+" /submit q=surf", bow.Body())
+	ut.AssertEquals(mock.Host, bow.Url().Host)
+
+	bow.SetURLRewriter(nil)
+	err = bow.Open("http://surf.example/old")
+	ut.AssertNotNil(err)
+}
+
+func TestProxySOCKS5(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, htmlPage1)
+	}))
+	defer ts.Close()
+	proxy, hosts := socks5Server(t, "user", "pass", ts.Listener.Addr().String())
+	defer proxy.Close()
+
+	bow := NewBrowser()
+	err := bow.SetProxy("socks5://user:pass@" + proxy.Addr().String())
+	ut.AssertNil(err)
+	err = bow.Open("http://surf.example/page")
+	ut.AssertNil(err)
+	ut.AssertEquals("Surf Page 1", bow.Title())
+	ut.AssertEquals("surf.example:80", <-hosts)
+
+	err = bow.SetProxy("socks5h://user:pass@" + proxy.Addr().String())
+	ut.AssertNil(err)
+	err = bow.Open("http://other.example/page")
+	ut.AssertNil(err)
+	ut.AssertEquals("other.example:80", <-hosts)
+
+	err = bow.SetProxy("socks5://user:wrong@" + proxy.Addr().String())
+	ut.AssertNil(err)
+	err = bow.Open("http://surf.example/page")
+	ut.AssertNotNil(err)
+}
+
+// socks5Server starts a SOCKS5 proxy requiring the given credentials, which
+// connects every request to the target address and sends the requested
+// host to the returned channel.
+func socks5Server(t *testing.T, user, pass, target string) (net.Listener, chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				host, ok := socks5Handshake(conn, user, pass)
+				if !ok {
+					return
+				}
+				hosts <- host
+				upstream, err := net.Dial("tcp", target)
+				if err != nil {
+					return
+				}
+				defer upstream.Close()
+				go io.Copy(upstream, conn)
+				io.Copy(conn, upstream)
+			}()
+		}
+	}()
+	return l, hosts
+}
+
+// socks5Handshake authenticates a SOCKS5 connect request with the given
+// credentials, and returns the requested host and port.
+func socks5Handshake(conn net.Conn, user, pass string) (string, bool) {
+	buf := make([]byte, 256)
+	read := func(n int) []byte {
+		if _, err := io.ReadFull(conn, buf[:n]); err != nil {
+			return nil
+		}
+		return buf[:n]
+	}
+	if b := read(2); b == nil || b[0] != 5 || read(int(b[1])) == nil {
+		return "", false
+	}
+	conn.Write([]byte{5, 2})
+
+	b := read(2)
+	if b == nil {
+		return "", false
+	}
+	u := string(read(int(b[1])))
+	b = read(1)
+	if b == nil {
+		return "", false
+	}
+	p := string(read(int(b[0])))
+	if u != user || p != pass {
+		conn.Write([]byte{1, 1})
+		return "", false
+	}
+	conn.Write([]byte{1, 0})
+
+	b = read(4)
+	if b == nil || b[1] != 1 {
+		return "", false
+	}
+	var host string
+	switch b[3] {
+	case 1:
+		host = net.IP(read(4)).String()
+	case 3:
+		host = string(read(int(read(1)[0])))
+	case 4:
+		host = net.IP(read(16)).String()
+	}
+	b = read(2)
+	if b == nil {
+		return "", false
+	}
+	port := int(b[0])<<8 | int(b[1])
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	return net.JoinHostPort(host, fmt.Sprint(port)), true
+}
+
+func TestOpenDataUrl(t *testing.T) {
+	ut.Run(t)
+	bow := NewBrowser()
+
+	err := bow.Open("data:text/html,<title>Data Page</title><a href=\"?q=1%23top\">Next</a>")
+	ut.AssertNil(err)
+	ut.AssertEquals("Data Page", bow.Title())
+	ut.AssertEquals("?q=1#top", bow.Find("a").AttrOr("href", ""))
+
+	err = bow.Open("data:text/html;base64,PHRpdGxlPkJhc2UgNjQ8L3RpdGxlPg==")
+	ut.AssertNil(err)
+	ut.AssertEquals("Base 64", bow.Title())
+	ut.AssertEquals(2, bow.HistoryLength())
+
+	err = bow.Open("data:text/html;charset=iso-8859-1,%3Ctitle%3ECaf%E9%3C/title%3E")
+	ut.AssertNil(err)
+	ut.AssertEquals("Café", bow.Title())
+
+	err = bow.Open("data:,Hello%2C%20World!")
+	ut.AssertNil(err)
+	ut.AssertEquals("Hello, World!", string(bow.RawBody()))
+	ut.AssertEquals("text/plain;charset=US-ASCII", bow.ResponseHeaders().Get("Content-Type"))
+	ut.AssertEquals(200, bow.StatusCode())
+
+	err = bow.Open("data:application/json;base64,eyJvayI6dHJ1ZX0")
+	ut.AssertNil(err)
+	ut.AssertEquals(`{"ok":true}`, string(bow.RawBody()))
+
+	err = bow.Open("data:text/html;base64,not base64!")
+	ut.AssertNotNil(err)
+	err = bow.Open("data:text/html")
+	ut.AssertNotNil(err)
+}
+
+func TestMetaDescription(t *testing.T) {
+	ut.Run(t)
+	bow := NewBrowser()
+
+	err := bow.OpenString(`<html><head>
+		<title>
+			Surf Docs
+		</title>
+		<meta name="Description" content="  Stateful web browsing. ">
+	</head><body><svg><title>Icon</title></svg></body></html>`)
+	ut.AssertNil(err)
+	ut.AssertEquals("Surf Docs", bow.Title())
+	ut.AssertEquals("Stateful web browsing.", bow.MetaDescription())
+
+	err = bow.OpenString(`<p>No head</p>`)
+	ut.AssertNil(err)
+	ut.AssertEquals("", bow.Title())
+	ut.AssertEquals("", bow.MetaDescription())
+}
+
+func TestHTTPErrors(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/limited" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, "<title>Slow down</title>")
+			return
+		}
+		fmt.Fprint(w, htmlPage1)
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	err := bow.Open(ts.URL + "/limited")
+	ut.AssertNil(err)
+	ut.AssertEquals(http.StatusTooManyRequests, bow.StatusCode())
+
+	bow.SetAttribute(browser.HTTPErrors, true)
+	err = bow.Open(ts.URL + "/limited")
+	ut.AssertNotNil(err)
+	httpErr, ok := err.(errors.HTTPError)
+	ut.AssertTrue(ok)
+	ut.AssertEquals(http.StatusTooManyRequests, httpErr.StatusCode)
+	ut.AssertEquals("<title>Slow down</title>", string(httpErr.Body))
+	ut.AssertEquals("Slow down", bow.Title())
+
+	err = bow.Open(ts.URL)
+	ut.AssertNil(err)
+}
+
+func TestOpenReader(t *testing.T) {
+	ut.Run(t)
+	bow := NewBrowser()
+
+	err := bow.OpenString(htmlPage1)
+	ut.AssertNil(err)
+	ut.AssertEquals("Surf Page 1", bow.Title())
+	ut.AssertEquals("about:blank", bow.Url().String())
+
+	err = bow.OpenReaderWithUrl(strings.NewReader(htmlLinks), "http://surf.example/dir/page")
+	ut.AssertNil(err)
+	ut.AssertEquals(2, bow.HistoryLength())
+	ut.AssertEquals("http://surf.example/dir/page", bow.Url().String())
+	u, err := bow.ResolveStringUrl("other")
+	ut.AssertNil(err)
+	ut.AssertEquals("http://surf.example/dir/other", u)
+
+	ut.AssertTrue(bow.Back())
+	ut.AssertEquals("Surf Page 1", bow.Title())
+	err = bow.OpenReaderWithUrl(strings.NewReader(htmlPage1), "http://[::1")
+	ut.AssertNotNil(err)
+}
+
+func TestMetaRefresh(t *testing.T) {
+	ut.Run(t)
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		switch req.URL.Path {
+		case "/start":
+			fmt.Fprint(w, refreshPage("60; url=/target"))
+		case "/loop1":
+			fmt.Fprint(w, refreshPage("0;URL='/loop2'"))
+		case "/loop2":
+			fmt.Fprint(w, refreshPage("0, url=/loop1"))
+		default:
+			fmt.Fprint(w, htmlPage2)
+		}
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	bow.SetMetaRefresh(true, 10*time.Millisecond)
+	err := bow.Open(ts.URL + "/start")
+	ut.AssertNil(err)
+	ut.AssertEquals(int32(2), atomic.LoadInt32(&hits))
+	ut.AssertEquals("Surf Page 2", bow.Title())
+	ut.AssertEquals(ts.URL+"/target", bow.Url().String())
+
+	atomic.StoreInt32(&hits, 0)
+	err = bow.Open(ts.URL + "/loop1")
+	ut.AssertNil(err)
+	ut.AssertEquals(int32(3), atomic.LoadInt32(&hits))
+	ut.AssertEquals(ts.URL+"/loop1", bow.Url().String())
+
+	atomic.StoreInt32(&hits, 0)
+	bow.SetMetaRefresh(true, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = bow.OpenWithContext(ctx, ts.URL+"/start")
+	ut.AssertEquals(context.DeadlineExceeded, err)
+	ut.AssertTrue(time.Since(start) < time.Second)
+	ut.AssertEquals(int32(1), atomic.LoadInt32(&hits))
+	ut.AssertEquals(ts.URL+"/start", bow.Url().String())
+
+	atomic.StoreInt32(&hits, 0)
+	bow.SetMetaRefresh(false, 0)
+	err = bow.Open(ts.URL + "/start")
+	ut.AssertNil(err)
+	ut.AssertEquals(int32(1), atomic.LoadInt32(&hits))
+}
+
+func TestDocument(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/page2" {
+			fmt.Fprint(w, htmlPage2)
+		} else {
+			fmt.Fprint(w, htmlPage1)
+		}
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	ut.AssertNil(bow.Document())
+
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals("Surf Page 1", bow.Document().Find("title").Text())
+	ut.AssertEquals(ts.URL, bow.Document().Url.String())
+
+	err = bow.Open(ts.URL + "/page2")
+	ut.AssertNil(err)
+	ut.AssertEquals("Surf Page 2", bow.Document().Find("title").Text())
+}
+
+func TestMaxBodySize(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/stream" {
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, htmlPage1)
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	bow.SetMaxBodySize(100)
+	err := bow.Open(ts.URL)
+	_, ok := err.(errors.BodyTooLarge)
+	ut.AssertTrue(ok)
+	ut.AssertContains("bytes, the limit is 100 bytes", err.Error())
+
+	err = bow.Open(ts.URL + "/stream")
+	_, ok = err.(errors.BodyTooLarge)
+	ut.AssertTrue(ok)
+	ut.AssertContains("larger than the limit of 100 bytes", err.Error())
+
+	bow.SetMaxBodySize(int64(len(htmlPage1)))
+	err = bow.Open(ts.URL + "/stream")
+	ut.AssertNil(err)
+	ut.AssertEquals("Surf Page 1", bow.Title())
+}
+
+func TestCache(t *testing.T) {
+	ut.Run(t)
+	hits := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		hits[req.URL.Path]++
+		switch req.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/etag":
+			if req.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+		case "/nostore":
+			w.Header().Set("Cache-Control", "no-store, max-age=60")
+		}
+		fmt.Fprint(w, htmlPage1)
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	bow.SetCache(jar.NewMemoryCache())
+	for _, path := range []string{"/fresh", "/etag", "/nostore"} {
+		ut.AssertNil(bow.Open(ts.URL + path))
+		ut.AssertNil(bow.Open(ts.URL + path))
+		ut.AssertEquals("Surf Page 1", bow.Title())
+		ut.AssertEquals(200, bow.StatusCode())
+	}
+	ut.AssertEquals(1, hits["/fresh"])
+	ut.AssertEquals(2, hits["/etag"])
+	ut.AssertEquals(2, hits["/nostore"])
+}
+
+func TestLastRequestResponse(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/old" {
+			http.Redirect(w, req, "/new", http.StatusTemporaryRedirect)
+			return
+		}
+		body, _ := ioutil.ReadAll(req.Body)
+		w.Header().Set("X-Path", req.URL.Path)
+		fmt.Fprintf(w, "<p>%s %s</p>", req.Method, body)
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	ut.AssertTrue(bow.LastRequest() == nil)
+	ut.AssertTrue(bow.LastResponse() == nil)
+
+	err := bow.Post(ts.URL+"/old", "text/plain", strings.NewReader("a=1"))
+	ut.AssertNil(err)
+	req := bow.LastRequest()
+	ut.AssertEquals("POST", req.Method)
+	ut.AssertEquals(ts.URL+"/old", req.URL.String())
+	body, _ := ioutil.ReadAll(req.Body)
+	ut.AssertEquals("a=1", string(body))
+
+	resp := bow.LastResponse()
+	ut.AssertEquals(200, resp.StatusCode)
+	ut.AssertEquals("/new", resp.Header.Get("X-Path"))
+	ut.AssertEquals(ts.URL+"/new", resp.Request.URL.String())
+	for i := 0; i < 2; i++ {
+		body, _ = ioutil.ReadAll(bow.LastResponse().Body)
+		ut.AssertEquals("<p>POST a=1</p>", string(body))
+	}
+	ut.AssertEquals("POST a=1", bow.Find("p").Text())
+
+	bow.SetAttribute(browser.ReloadPost, true)
+	err = bow.Reload()
+	ut.AssertNil(err)
+	ut.AssertEquals("POST a=1", bow.Find("p").Text())
+}
+
+func TestLastFromCache(t *testing.T) {
+	ut.Run(t)
+	hits := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		hits[req.URL.Path]++
+		if req.URL.Path == "/etag" {
+			if req.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+		} else {
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+		fmt.Fprint(w, htmlPage1)
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	bow.SetCache(jar.NewMemoryCache())
+	ut.AssertFalse(bow.LastFromCache())
+	ut.AssertNil(bow.Open(ts.URL + "/fresh"))
+	ut.AssertFalse(bow.LastFromCache())
+	ut.AssertNil(bow.Open(ts.URL + "/fresh"))
+	ut.AssertTrue(bow.LastFromCache())
+	ut.AssertNil(bow.ReloadBypassingCache())
+	ut.AssertFalse(bow.LastFromCache())
+	ut.AssertEquals(2, hits["/fresh"])
+	ut.AssertNil(bow.Reload())
+	ut.AssertTrue(bow.LastFromCache())
+	ut.AssertEquals(2, hits["/fresh"])
+
+	ut.AssertNil(bow.Open(ts.URL + "/etag"))
+	ut.AssertFalse(bow.LastFromCache())
+	ut.AssertNil(bow.Open(ts.URL + "/etag"))
+	ut.AssertTrue(bow.LastFromCache())
+	ut.AssertNil(bow.ReloadBypassingCache())
+	ut.AssertFalse(bow.LastFromCache())
+	ut.AssertEquals(200, bow.StatusCode())
+	ut.AssertEquals(3, hits["/etag"])
+}
+
+func TestSetHeaders(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "%s|%s|%s", req.Header.Get("Accept-Language"), req.Header.Get("Accept"), req.Header["User-Agent"])
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	bow.AddRequestHeader("X-Old", "1")
+	h := http.Header{}
+	h.Set("Accept-Language", "es-UY")
+	h.Set("Accept", "text/html")
+	bow.SetHeaders(h)
+	h.Set("Accept", "changed")
+	ut.AssertEquals("", bow.Headers().Get("X-Old"))
+	ut.AssertEquals("text/html", bow.Headers().Get("Accept"))
+	bow.Headers().Set("Accept", "changed")
+	ut.AssertEquals("text/html", bow.Headers().Get("Accept"))
+
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals("es-UY|text/html|["+DefaultUserAgent+"]", bow.Body())
+
+	err = bow.OpenWithHeaders(ts.URL, http.Header{"Accept": {"application/json"}, "User-Agent": {"Once/1.0"}})
+	ut.AssertNil(err)
+	ut.AssertEquals("es-UY|application/json|[Once/1.0]", bow.Body())
+
+	bow.SetHeaders(nil)
+	err = bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals("||["+DefaultUserAgent+"]", bow.Body())
+}
+
+func TestSetAcceptLanguage(t *testing.T) {
+	ut.Run(t)
+	bow := NewBrowser()
+	bow.AddRequestHeader("Accept-Language", "de")
+
+	bow.SetAcceptLanguage("en-US", "en", " ", "fr")
+	ut.AssertEquals([]string{"en-US,en;q=0.9,fr;q=0.8"}, bow.Headers()["Accept-Language"])
+
+	langs := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"}
+	bow.SetAcceptLanguage(langs...)
+	ut.AssertEquals("a,b;q=0.9,c;q=0.8,d;q=0.7,e;q=0.6,f;q=0.5,g;q=0.4,h;q=0.3,i;q=0.2,j;q=0.1,k;q=0.1,l;q=0.1",
+		bow.Headers().Get("Accept-Language"))
+
+	bow.SetAcceptLanguage()
+	ut.AssertEquals("", bow.Headers().Get("Accept-Language"))
+}
+
+func TestOpenXHR(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Requested-With") != "XMLHttpRequest" {
+			fmt.Fprint(w, htmlPage1)
+			return
+		}
+		body, _ := ioutil.ReadAll(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"method":  req.Method,
+			"accept":  req.Header.Get("Accept"),
+			"type":    req.Header.Get("Content-Type"),
+			"referer": req.Referer(),
+			"body":    string(body),
+		})
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	err := bow.Open(ts.URL + "/page")
+	ut.AssertNil(err)
+	err = bow.OpenXHR("post", ts.URL+"/api", strings.NewReader("q=surf"))
+	ut.AssertNil(err)
+	got := map[string]string{}
+	ut.AssertNil(bow.DecodeJSON(&got))
+	ut.AssertEquals("POST", got["method"])
+	ut.AssertContains("application/json", got["accept"])
+	ut.AssertEquals("application/x-www-form-urlencoded; charset=UTF-8", got["type"])
+	ut.AssertEquals(ts.URL+"/page", got["referer"])
+	ut.AssertEquals("q=surf", got["body"])
+
+	err = bow.OpenXHR("GET", ts.URL+"/api", nil)
+	ut.AssertNil(err)
+	ut.AssertNil(bow.DecodeJSON(&got))
+	ut.AssertEquals("GET", got["method"])
+	ut.AssertEquals("", got["type"])
+}
+
+func TestRawBody(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/api" {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"name":"surf","tags":["<a>"]}`)
+			return
+		}
+		fmt.Fprint(w, htmlPage1)
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	err := bow.Open(ts.URL + "/api")
+	ut.AssertNil(err)
+	ut.AssertEquals(`{"name":"surf","tags":["<a>"]}`, string(bow.RawBody()))
+
+	err = bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals(htmlPage1, string(bow.RawBody()))
+	ut.AssertTrue(bow.Back())
+	ut.AssertContains("surf", string(bow.RawBody()))
+}
+
+func TestContentTypeParsing(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/api":
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			fmt.Fprint(w, `{"html":"<a href='/x'>x</a>","n":1}`)
+		case "/feed":
+			w.Header().Set("Content-Type", "application/rss+xml")
+			fmt.Fprint(w, `<rss><channel><title>Feed</title></channel></rss>`)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, htmlPage1)
+		}
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	err := bow.Open(ts.URL + "/api")
+	ut.AssertNil(err)
+	ut.AssertEquals(`{"html":"<a href='/x'>x</a>","n":1}`, bow.Body())
+	ut.AssertEquals(0, bow.Find("a").Length())
+	ut.AssertEquals(0, len(bow.Links()))
+	var got struct{ N int }
+	ut.AssertNil(bow.DecodeJSON(&got))
+	ut.AssertEquals(1, got.N)
+
+	err = bow.Open(ts.URL + "/feed")
+	ut.AssertNil(err)
+	ut.AssertEquals("", bow.Title())
+	ut.AssertEquals(0, bow.Find("channel").Length())
+	ut.AssertContains("<title>Feed</title>", bow.Body())
+
+	err = bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals("Surf Page 1", bow.Title())
+}
+
+func TestDecodeXML(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		if req.URL.Path == "/latin1" {
+			fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n<rss><channel><title>Caf\xe9</title></channel></rss>")
+			return
+		}
+		fmt.Fprint(w, `<rss><channel><title>Feed</title><item><title>One</title></item><item><title>Two</title></item></channel></rss>`)
+	}))
+	defer ts.Close()
+
+	type feed struct {
+		Title string   `xml:"channel>title"`
+		Items []string `xml:"channel>item>title"`
+	}
+
+	bow := NewBrowser()
+	var got feed
+	ut.AssertNotNil(bow.DecodeXML(&got))
+	err := bow.Open(ts.URL + "/rss")
+	ut.AssertNil(err)
+	ut.AssertNil(bow.DecodeXML(&got))
+	ut.AssertEquals("Feed", got.Title)
+	ut.AssertEquals([]string{"One", "Two"}, got.Items)
+
+	err = bow.Open(ts.URL + "/latin1")
+	ut.AssertNil(err)
+	got = feed{}
+	ut.AssertNil(bow.DecodeXML(&got))
+	ut.AssertEquals("Caf\u00e9", got.Title)
+
+	err = bow.OpenString("<rss>")
+	ut.AssertNil(err)
+	err = bow.DecodeXML(&got)
+	ut.AssertNotNil(err)
+	ut.AssertContains("as XML", err.Error())
+}
+
+func TestPostJSON(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"type":%q,"echo":%s}`, req.Header.Get("Content-Type"), body)
+	}))
+	defer ts.Close()
+
+	type payload struct {
+		Name string `json:"name"`
+	}
+	var out struct {
+		Type string  `json:"type"`
+		Echo payload `json:"echo"`
+	}
+
+	bow := NewBrowser()
+	ut.AssertNotNil(bow.DecodeJSON(&out))
+	err := bow.PostJSON(ts.URL, payload{Name: "surf"})
+	ut.AssertNil(err)
+	err = bow.DecodeJSON(&out)
+	ut.AssertNil(err)
+	ut.AssertEquals("application/json", out.Type)
+	ut.AssertEquals("surf", out.Echo.Name)
+
+	err = bow.PostJSON(ts.URL, make(chan int))
+	ut.AssertNotNil(err)
+	ut.AssertContains("as JSON", err.Error())
+}
+
+func TestCharsetDetection(t *testing.T) {
+	ut.Run(t)
+	page := func(meta string) []byte {
+		html := fmt.Sprintf("<html><head>%s<title>日本語のページ</title></head><body></body></html>", meta)
+		b, _ := japanese.ShiftJIS.NewEncoder().Bytes([]byte(html))
+		return b
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/header":
+			w.Header().Set("Content-Type", "text/html; charset=Shift_JIS")
+			w.Write(page(""))
+		case "/meta":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write(page(`<meta charset="shift_jis">`))
+		default:
+			fmt.Fprint(w, "<html><head><title>ページ</title></head></html>")
+		}
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	for _, path := range []string{"/header", "/meta"} {
+		err := bow.Open(ts.URL + path)
+		ut.AssertNil(err)
+		ut.AssertEquals("日本語のページ", bow.Title())
+		ut.AssertEquals(string(page(""))[:6], string(bow.RawBody())[:6])
+	}
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals("ページ", bow.Title())
+
+	bow.SetCharsetDetection(false)
+	err = bow.Open(ts.URL + "/header")
+	ut.AssertNil(err)
+	ut.AssertFalse(bow.Title() == "日本語のページ")
+}
+
+func TestPreRequestEvent(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, req.Header.Get("X-Signature"))
+	}))
+	defer ts.Close()
+
+	urls := []string{}
+	bow := NewBrowser()
+	bow.Events().On(event.PreRequest, func(payload interface{}) error {
+		req := payload.(*http.Request)
+		urls = append(urls, req.URL.Path)
+		req.Header.Set("X-Signature", "signed:"+req.Method)
+		return nil
+	})
+	err := bow.Open(ts.URL + "/page")
+	ut.AssertNil(err)
+	ut.AssertEquals("signed:GET", bow.Body())
+	err = bow.Post(ts.URL+"/form", "text/plain", strings.NewReader("surf"))
+	ut.AssertNil(err)
+	ut.AssertEquals("signed:POST", bow.Body())
+	ut.AssertEquals([]string{"/page", "/form"}, urls)
+}
+
+func TestPreRequestEventError(t *testing.T) {
+	ut.Run(t)
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		fmt.Fprint(w, htmlPage1)
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	bow.Events().On(event.PreRequest, func(payload interface{}) error {
+		return errors.New("not signed")
+	})
+	err := bow.Open(ts.URL)
+	ut.AssertNotNil(err)
+	ut.AssertEquals("not signed", err.Error())
+	ut.AssertEquals(0, requests)
+}
+
+func TestPostResponseEvent(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprint(w, htmlPage1)
+	}))
+	defer ts.Close()
+
+	var got *event.Response
+	var body []byte
+	bow := NewBrowser()
+	bow.Events().On(event.PostResponse, func(payload interface{}) error {
+		got = payload.(*event.Response)
+		body, _ = ioutil.ReadAll(got.Response.Body)
+		return nil
+	})
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertNotNil(got)
+	ut.AssertEquals(http.StatusAccepted, got.Response.StatusCode)
+	ut.AssertTrue(got.Elapsed >= 20*time.Millisecond)
+	ut.AssertEquals(htmlPage1, string(body))
+	ut.AssertEquals("Surf Page 1", bow.Title())
+}
+
+func TestNavigateEvents(t *testing.T) {
+	ut.Run(t)
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		switch req.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<a href="/old">Next</a><form method="post" action="/save"><input name="q" value="1"></form>`)
+		case "/old":
+			http.Redirect(w, req, "/new", http.StatusFound)
+		case "/missing":
+			http.NotFound(w, req)
+		default:
+			fmt.Fprint(w, htmlPage1)
+		}
+	}))
+	defer ts.Close()
+
+	seen := []string{}
+	bow := NewBrowser()
+	bow.Events().On(event.NavigateStart, func(payload interface{}) error {
+		nav := payload.(*event.Navigation)
+		seen = append(seen, "start "+nav.Method+" "+strings.TrimPrefix(nav.Url, ts.URL))
+		if strings.HasSuffix(nav.Url, "/blocked") {
+			return errors.New("blocked")
+		}
+		return nil
+	})
+	bow.Events().On(event.NavigateFinish, func(payload interface{}) error {
+		res := payload.(*event.NavigationResult)
+		seen = append(seen, fmt.Sprintf("finish %s %s %d", res.Method, strings.TrimPrefix(res.Url, ts.URL), res.StatusCode))
+		return errors.New("ignored")
+	})
+	bow.Events().On(event.NavigateError, func(payload interface{}) error {
+		nerr := payload.(*event.NavigationError)
+		seen = append(seen, "error "+nerr.Method+" "+strings.TrimPrefix(nerr.Url, ts.URL)+" "+nerr.Err.Error())
+		return nil
+	})
+
+	err := bow.Open(ts.URL + "/")
+	ut.AssertNil(err)
+	err = bow.Click("a")
+	ut.AssertNil(err)
+	ut.AssertTrue(bow.Back())
+	f, err := bow.Form("form")
+	ut.AssertNil(err)
+	err = f.Submit()
+	ut.AssertNil(err)
+	err = bow.Open(ts.URL + "/blocked")
+	ut.AssertNotNil(err)
+	ut.AssertEquals(int32(4), atomic.LoadInt32(&hits))
+
+	bow.SetAttribute(browser.HTTPErrors, true)
+	err = bow.Open(ts.URL + "/missing")
+	ut.AssertNotNil(err)
+
+	ut.AssertEquals([]string{
+		"start GET /",
+		"finish GET / 200",
+		"start GET /old",
+		"finish GET /new 200",
+		"start POST /save",
+		"finish POST /save 200",
+		"start GET /blocked",
+		"error GET /blocked blocked",
+		"start GET /missing",
+		"error GET /missing HTTP Error: Request to '" + ts.URL + "/missing' failed with status '404 Not Found'.",
+	}, seen)
+}
+
 func TestDownload(t *testing.T) {
 	ut.Run(t)
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
@@ -55,11 +1574,94 @@ func TestDownload(t *testing.T) {
 	buff := &bytes.Buffer{}
 	l, err := bow.Download(buff)
 	ut.AssertNil(err)
-	ut.AssertGreaterThan(0, int(l))
-	ut.AssertEquals(int(l), buff.Len())
+	ut.AssertGreaterThan(0, int(l))
+	ut.AssertEquals(int(l), buff.Len())
+}
+
+func TestDownloadWithProgress(t *testing.T) {
+	ut.Run(t)
+	data := strings.Repeat("surf", 50000)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/file":
+			w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+			fmt.Fprint(w, data)
+		case "/missing":
+			http.NotFound(w, req)
+		default:
+			fmt.Fprint(w, htmlPage1)
+		}
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+
+	buff := &bytes.Buffer{}
+	calls, written, total := 0, int64(0), int64(0)
+	n, err := bow.DownloadWithProgress("/file", buff, func(w, t int64) {
+		calls++
+		written, total = w, t
+	})
+	ut.AssertNil(err)
+	ut.AssertEquals(int64(len(data)), n)
+	ut.AssertEquals(data, buff.String())
+	ut.AssertGreaterThan(1, calls)
+	ut.AssertEquals(n, written)
+	ut.AssertEquals(n, total)
+	ut.AssertEquals("Surf Page 1", bow.Title())
+
+	_, err = bow.DownloadWithProgress("/missing", buff, nil)
+	ut.AssertNotNil(err)
+}
+
+func TestHead(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "1"})
+		}
+		if req.Method == "HEAD" {
+			w.Header().Set("X-Agent", req.UserAgent())
+			if _, err := req.Cookie("session"); err != nil {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "image/png")
+		fmt.Fprint(w, htmlPage1)
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	bow.SetUserAgent("Testing/1.0")
+	err := bow.Open(ts.URL + "/login")
+	ut.AssertNil(err)
+
+	headers, status, err := bow.Head(ts.URL + "/image.png")
+	ut.AssertNil(err)
+	ut.AssertEquals(200, status)
+	ut.AssertEquals("image/png", headers.Get("Content-Type"))
+	ut.AssertEquals("Testing/1.0", headers.Get("X-Agent"))
+	ut.AssertEquals(ts.URL+"/login", bow.Url().String())
+}
+
+func TestUserAgent(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, req.UserAgent())
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	bow.SetUserAgent("Testing/1.0")
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals("Testing/1.0", bow.Body())
 }
 
-func TestUserAgent(t *testing.T) {
+func TestUserAgents(t *testing.T) {
 	ut.Run(t)
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		fmt.Fprint(w, req.UserAgent())
@@ -67,6 +1669,22 @@ func TestUserAgent(t *testing.T) {
 	defer ts.Close()
 
 	bow := NewBrowser()
+	bow.SetUserAgents([]string{"Agent/1", "Agent/2"})
+	for _, expected := range []string{"Agent/1", "Agent/2", "Agent/1"} {
+		err := bow.Open(ts.URL)
+		ut.AssertNil(err)
+		ut.AssertEquals(expected, bow.Body())
+		ut.AssertEquals(expected, bow.UserAgent())
+	}
+
+	bow.SetAttribute(browser.RandomUserAgent, true)
+	for i := 0; i < 5; i++ {
+		err := bow.Open(ts.URL)
+		ut.AssertNil(err)
+		ut.AssertContains("Agent/", bow.Body())
+		ut.AssertEquals(bow.Body(), bow.UserAgent())
+	}
+
 	bow.SetUserAgent("Testing/1.0")
 	err := bow.Open(ts.URL)
 	ut.AssertNil(err)
@@ -90,6 +1708,359 @@ func TestHeaders(t *testing.T) {
 	ut.AssertContains("Testing-2", bow.Body())
 }
 
+func TestResponseHeaders(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/old" {
+			w.Header().Set("ETag", "old")
+			http.Redirect(w, req, "/new", http.StatusFound)
+			return
+		}
+		w.Header().Set("ETag", "new")
+		w.Header().Set("Last-Modified", "Wed, 21 Oct 2015 07:28:00 GMT")
+		fmt.Fprint(w, htmlPage1)
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	err := bow.Open(ts.URL + "/old")
+	ut.AssertNil(err)
+	ut.AssertEquals("new", bow.ResponseHeaders().Get("ETag"))
+	ut.AssertEquals("Wed, 21 Oct 2015 07:28:00 GMT", bow.ResponseHeaders().Get("Last-Modified"))
+	ut.AssertContains("text/html", bow.ResponseHeaders().Get("Content-Type"))
+}
+
+func TestUrl(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/start":
+			http.Redirect(w, req, "/middle", http.StatusFound)
+		case "/middle":
+			http.Redirect(w, req, "/dir/final", http.StatusFound)
+		default:
+			fmt.Fprint(w, htmlPage1)
+		}
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	err := bow.Open(ts.URL + "/start")
+	ut.AssertNil(err)
+	ut.AssertEquals(ts.URL+"/dir/final", bow.Url().String())
+
+	href, err := bow.ResolveStringUrl("page2")
+	ut.AssertNil(err)
+	ut.AssertEquals(ts.URL+"/dir/page2", href)
+
+	err = bow.Open(ts.URL + "/other")
+	ut.AssertNil(err)
+	ut.AssertEquals(ts.URL+"/other", bow.Url().String())
+}
+
+func TestMaxRedirects(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/loop1":
+			http.Redirect(w, req, "/loop2", http.StatusFound)
+		case "/loop2":
+			http.Redirect(w, req, "/loop1", http.StatusFound)
+		case "/once":
+			http.Redirect(w, req, "/", http.StatusFound)
+		default:
+			fmt.Fprint(w, htmlPage1)
+		}
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	bow.SetMaxRedirects(3)
+	err := bow.Open(ts.URL + "/loop1")
+	ut.AssertNotNil(err)
+	ut.AssertContains("Stopped after 3 redirects", err.Error())
+	ut.AssertContains(ts.URL+"/loop1 -> "+ts.URL+"/loop2", err.Error())
+
+	err = bow.Open(ts.URL + "/once")
+	ut.AssertNil(err)
+	ut.AssertEquals("Surf Page 1", bow.Title())
+
+	bow.SetMaxRedirects(0)
+	err = bow.Open(ts.URL + "/once")
+	ut.AssertNotNil(err)
+	ut.AssertContains("Redirects are disabled", err.Error())
+}
+
+func TestRedirectMethodPolicy(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/end" {
+			body, _ := ioutil.ReadAll(req.Body)
+			fmt.Fprintf(w, "%s|%s|%s", req.Method, body, req.Header.Get("Content-Type"))
+			return
+		}
+		code, _ := strconv.Atoi(req.URL.Query().Get("code"))
+		http.Redirect(w, req, "/end", code)
+	}))
+	defer ts.Close()
+
+	post := func(bow *browser.Browser, code int) string {
+		// The reader cannot be rewound, so the browser has to buffer it.
+		body := io.MultiReader(strings.NewReader("a=1"))
+		err := bow.Post(fmt.Sprintf("%s/start?code=%d", ts.URL, code), "text/plain", body)
+		ut.AssertNil(err)
+		return string(bow.RawBody())
+	}
+	bow := NewBrowser()
+	ut.AssertEquals("GET||", post(bow, http.StatusMovedPermanently))
+	ut.AssertEquals("GET||", post(bow, http.StatusFound))
+	ut.AssertEquals("GET||", post(bow, http.StatusSeeOther))
+	ut.AssertEquals("POST|a=1|text/plain", post(bow, http.StatusTemporaryRedirect))
+	ut.AssertEquals("POST|a=1|text/plain", post(bow, http.StatusPermanentRedirect))
+
+	err := bow.OpenXHR("PUT", ts.URL+"/start?code=302", strings.NewReader("b=2"))
+	ut.AssertNil(err)
+	ut.AssertEquals("PUT|b=2|application/x-www-form-urlencoded; charset=UTF-8", string(bow.RawBody()))
+	err = bow.OpenXHR("PUT", ts.URL+"/start?code=303", strings.NewReader("b=2"))
+	ut.AssertNil(err)
+	ut.AssertEquals("GET||", string(bow.RawBody()))
+
+	bow.SetRedirectMethodPolicy(func(status int, method string) string {
+		return method
+	})
+	ut.AssertEquals("POST|a=1|text/plain", post(bow, http.StatusSeeOther))
+	bow.SetRedirectMethodPolicy(func(status int, method string) string {
+		return "GET"
+	})
+	ut.AssertEquals("GET||", post(bow, http.StatusTemporaryRedirect))
+	bow.SetRedirectMethodPolicy(nil)
+	ut.AssertEquals("GET||", post(bow, http.StatusFound))
+}
+
+func TestStatusCode(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, htmlPage2)
+			return
+		}
+		fmt.Fprint(w, htmlPage1)
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals(200, bow.StatusCode())
+
+	err = bow.Open(ts.URL + "/missing")
+	ut.AssertNil(err)
+	ut.AssertEquals(404, bow.StatusCode())
+	ut.AssertEquals("Surf Page 2", bow.Title())
+}
+
+func TestSetCookie(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, req.Header.Get("Cookie"))
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	bow := NewBrowser()
+	err := bow.SetCookie(u, &http.Cookie{Name: "token", Value: "t1"})
+	ut.AssertNil(err)
+	err = bow.SetCookie(u, &http.Cookie{Name: "other", Value: "o1", Domain: "example.com"})
+	ut.AssertNotNil(err)
+
+	cookies := bow.Cookies(u)
+	ut.AssertEquals(1, len(cookies))
+	ut.AssertEquals("t1", cookies[0].Value)
+	err = bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals("token=t1", bow.Body())
+
+	buff := &bytes.Buffer{}
+	ut.AssertNil(bow.SaveCookies(buff))
+	ut.AssertContains(`"name":"token"`, buff.String())
+}
+
+func TestSaveCookies(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/app/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1", HttpOnly: true})
+			http.SetCookie(w, &http.Cookie{Name: "prefs", Value: "p1", MaxAge: 3600})
+			http.SetCookie(w, &http.Cookie{Name: "old", Value: "o1", MaxAge: -1})
+		}
+		if req.URL.Path == "/evil" {
+			// The jar rejects cookies for other domains, and for public
+			// suffixes.
+			http.SetCookie(w, &http.Cookie{Name: "evil", Value: "1", Domain: "bank.com"})
+			http.SetCookie(w, &http.Cookie{Name: "tld", Value: "1", Domain: "com"})
+			http.SetCookie(w, &http.Cookie{Name: "kept", Value: "1", Path: "/elsewhere"})
+		}
+		fmt.Fprint(w, req.Header.Get("Cookie"))
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	err := bow.Open(ts.URL + "/app/login")
+	ut.AssertNil(err)
+
+	buff := &bytes.Buffer{}
+	err = bow.SaveCookies(buff)
+	ut.AssertNil(err)
+	ut.AssertContains(`"name":"session"`, buff.String())
+	ut.AssertContains(`"path":"/app"`, buff.String())
+	ut.AssertContains(`"http_only":true`, buff.String())
+	ut.AssertFalse(strings.Contains(buff.String(), `"old"`))
+
+	bow = NewBrowser()
+	err = bow.LoadCookies(buff)
+	ut.AssertNil(err)
+	err = bow.Open(ts.URL + "/app/home")
+	ut.AssertNil(err)
+	ut.AssertContains("session=s1", bow.Body())
+	ut.AssertContains("prefs=p1", bow.Body())
+	err = bow.Open(ts.URL + "/other")
+	ut.AssertNil(err)
+	ut.AssertEquals("", bow.Body())
+
+	err = bow.Open(ts.URL + "/evil")
+	ut.AssertNil(err)
+	buff.Reset()
+	err = bow.SaveCookies(buff)
+	ut.AssertNil(err)
+	ut.AssertContains(`"name":"kept"`, buff.String())
+	ut.AssertFalse(strings.Contains(buff.String(), "bank.com"))
+	ut.AssertFalse(strings.Contains(buff.String(), `"domain":"com"`))
+
+	host := strings.Split(strings.TrimPrefix(ts.URL, "http://"), ":")[0]
+	expired := fmt.Sprintf(`[{"name":"expired","value":"e1","domain":"%s","path":"/","expires":"2001-01-01T00:00:00Z","host_only":true}]`, host)
+	bow = NewBrowser()
+	err = bow.LoadCookies(strings.NewReader(expired))
+	ut.AssertNil(err)
+	err = bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals("", bow.Body())
+}
+
+func TestCookiesNetscape(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/app/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1", HttpOnly: true})
+			http.SetCookie(w, &http.Cookie{Name: "prefs", Value: "p1", MaxAge: 3600})
+			// The jar rejects cookies for other domains.
+			http.SetCookie(w, &http.Cookie{Name: "evil", Value: "1", Domain: "bank.com"})
+		}
+		fmt.Fprint(w, req.Header.Get("Cookie"))
+	}))
+	defer ts.Close()
+	host := strings.Split(strings.TrimPrefix(ts.URL, "http://"), ":")[0]
+
+	bow := NewBrowser()
+	err := bow.Open(ts.URL + "/app/login")
+	ut.AssertNil(err)
+
+	buff := &bytes.Buffer{}
+	err = bow.ExportCookiesNetscape(buff)
+	ut.AssertNil(err)
+	lines := strings.Split(strings.TrimSpace(buff.String()), "\n")
+	ut.AssertEquals(3, len(lines))
+	ut.AssertEquals("# Netscape HTTP Cookie File", lines[0])
+	ut.AssertTrue(strings.HasPrefix(lines[1], host+"\tFALSE\t/app\tFALSE\t"))
+	ut.AssertTrue(strings.HasSuffix(lines[1], "\tprefs\tp1"))
+	ut.AssertEquals("#HttpOnly_"+host+"\tFALSE\t/app\tFALSE\t0\tsession\ts1", lines[2])
+	ut.AssertFalse(strings.Contains(buff.String(), "bank.com"))
+	ut.AssertFalse(strings.Contains(buff.String(), "evil"))
+
+	bow = NewBrowser()
+	err = bow.ImportCookiesNetscape(buff)
+	ut.AssertNil(err)
+	err = bow.Open(ts.URL + "/app/home")
+	ut.AssertNil(err)
+	ut.AssertContains("session=s1", bow.Body())
+	ut.AssertContains("prefs=p1", bow.Body())
+
+	file := "# comment\n\n" +
+		host + "\tFALSE\t/\tFALSE\t978307200\texpired\te1\n" +
+		"#HttpOnly_" + host + "\tFALSE\t/\tFALSE\t0\tkept\tk1\r\n"
+	bow = NewBrowser()
+	err = bow.ImportCookiesNetscape(strings.NewReader(file))
+	ut.AssertNil(err)
+	err = bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals("kept=k1", bow.Body())
+
+	bow = NewBrowser()
+	err = bow.ImportCookiesNetscape(strings.NewReader(host + "\tFALSE\t/\tFALSE\n"))
+	ut.AssertNotNil(err)
+}
+
+func TestOpenWithHeaders(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "%s|%s", req.Header.Get("X-Requested-With"), req.Header.Get("Accept"))
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	bow.AddRequestHeader("Accept", "text/html")
+	err := bow.OpenWithHeaders(ts.URL, http.Header{"X-Requested-With": {"XMLHttpRequest"}})
+	ut.AssertNil(err)
+	ut.AssertEquals("XMLHttpRequest|text/html", bow.Body())
+
+	err = bow.OpenWithHeaders(ts.URL, http.Header{"accept": {"application/json"}})
+	ut.AssertNil(err)
+	ut.AssertEquals("|application/json", bow.Body())
+
+	err = bow.Open(ts.URL)
+	ut.AssertNil(err)
+	ut.AssertEquals("|text/html", bow.Body())
+}
+
+func TestReferrerPolicy(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/" {
+			fmt.Fprint(w, htmlReferer)
+			return
+		}
+		fmt.Fprint(w, req.Referer())
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	err := bow.Open(ts.URL + "/?page=1")
+	ut.AssertNil(err)
+	err = bow.Click("a")
+	ut.AssertNil(err)
+	ut.AssertEquals(ts.URL+"/?page=1", bow.Body())
+
+	ut.AssertTrue(bow.Back())
+	form, err := bow.Form("form")
+	ut.AssertNil(err)
+	ut.AssertNil(form.Submit())
+	ut.AssertEquals(ts.URL+"/?page=1", bow.Find("body").Text())
+
+	ut.AssertTrue(bow.Back())
+	ut.AssertNil(bow.SetReferrerPolicy("origin"))
+	err = bow.Click("a")
+	ut.AssertNil(err)
+	ut.AssertEquals(ts.URL+"/", bow.Body())
+
+	ut.AssertTrue(bow.Back())
+	ut.AssertNil(bow.SetReferrerPolicy("no-referrer"))
+	err = bow.Click("a")
+	ut.AssertNil(err)
+	ut.AssertEquals("", bow.Body())
+
+	ut.AssertNotNil(bow.SetReferrerPolicy("sometimes"))
+}
+
 func TestBookmarks(t *testing.T) {
 	ut.Run(t)
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
@@ -132,6 +2103,37 @@ func TestClick(t *testing.T) {
 	ut.AssertContains("<p>Hello, Surf!</p>", bow.Body())
 }
 
+func TestFollowLink(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/dir/" {
+			fmt.Fprint(w, htmlLinks)
+			return
+		}
+		fmt.Fprint(w, req.URL.Path)
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	err := bow.Open(ts.URL + "/dir/")
+	ut.AssertNil(err)
+	err = bow.FollowLink("  relative path ")
+	ut.AssertNil(err)
+	ut.AssertEquals("/dir/page3", bow.Body())
+
+	ut.AssertTrue(bow.Back())
+	err = bow.FollowLinkRegex(regexp.MustCompile(`^dup`))
+	ut.AssertNil(err)
+	ut.AssertEquals("/page2", bow.Body())
+
+	ut.AssertTrue(bow.Back())
+	err = bow.FollowLink("Next")
+	ut.AssertNotNil(err)
+	ut.AssertContains("'Next'", err.Error())
+	_, ok := err.(errors.LinkNotFound)
+	ut.AssertTrue(ok)
+}
+
 func TestLinks(t *testing.T) {
 	ut.Run(t)
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
@@ -153,6 +2155,80 @@ func TestLinks(t *testing.T) {
 	ut.AssertEquals("no clicking", links[1].Text)
 }
 
+func TestLinkUrls(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, htmlLinks)
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	err := bow.Open(ts.URL + "/dir/")
+	ut.AssertNil(err)
+
+	urls := []string{}
+	for _, u := range bow.LinkUrls() {
+		urls = append(urls, u.String())
+	}
+	ut.AssertEquals([]string{
+		ts.URL + "/page2",
+		ts.URL + "/dir/page3",
+		"http://example.com/",
+	}, urls)
+}
+
+func TestBaseUrl(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, htmlBase)
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+
+	links := bow.Links()
+	ut.AssertEquals(1, len(links))
+	ut.AssertEquals("https://cdn.example.com/assets/page2", links[0].URL.String())
+
+	images := bow.Images()
+	ut.AssertEquals(1, len(images))
+	ut.AssertEquals("https://cdn.example.com/logo.png", images[0].URL.String())
+
+	stylesheets := bow.Stylesheets()
+	ut.AssertEquals(1, len(stylesheets))
+	ut.AssertEquals("https://cdn.example.com/assets/site.css", stylesheets[0].URL.String())
+
+	form, err := bow.Form("form")
+	ut.AssertNil(err)
+	ut.AssertEquals("https://cdn.example.com/assets/search", form.Action())
+	ut.AssertEquals(ts.URL, bow.Url().String())
+}
+
+func TestAssetUrls(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, htmlAssets)
+	}))
+	defer ts.Close()
+
+	bow := NewBrowser()
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+
+	strs := func(urls []*url.URL) []string {
+		s := []string{}
+		for _, u := range urls {
+			s = append(s, u.String())
+		}
+		return s
+	}
+	ut.AssertEquals([]string{ts.URL + "/a.png", "http://cdn.example.com/b.png"}, strs(bow.ImageUrls()))
+	ut.AssertEquals([]string{ts.URL + "/site.css", ts.URL + "/print.css"}, strs(bow.StylesheetUrls()))
+	ut.AssertEquals([]string{ts.URL + "/app.js"}, strs(bow.ScriptUrls()))
+}
+
 func TestImages(t *testing.T) {
 	ut.Run(t)
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
@@ -273,3 +2349,83 @@ var htmlPage2 = `<!doctype html>
 	</body>
 </html>
 `
+
+var htmlLinks = `<!doctype html>
+<html>
+	<head>
+		<title>Surf Links</title>
+	</head>
+	<body>
+		<a href="/page2">absolute path</a>
+		<a href="page3">relative path</a>
+		<a href="http://example.com/">other site</a>
+		<a href="/page2">duplicate</a>
+		<a href="javascript:void(0)">script</a>
+		<a href="mailto:surf@example.com">mail</a>
+		<a href="http://[::1">invalid</a>
+		<a name="anchor">no href</a>
+	</body>
+</html>
+`
+
+var htmlBase = `<!doctype html>
+<html>
+	<head>
+		<title>Surf Base</title>
+		<base href="https://cdn.example.com/assets/">
+		<link href="site.css" rel="stylesheet" />
+	</head>
+	<body>
+		<a href="page2">relative</a>
+		<img src="/logo.png" />
+		<form action="search"></form>
+	</body>
+</html>
+`
+
+var htmlAssets = `<!doctype html>
+<html>
+	<head>
+		<title>Surf Assets</title>
+		<link href="/favicon.ico" rel="icon" />
+		<link href="/site.css" rel="stylesheet" />
+		<link href="/print.css" rel="alternate Stylesheet" media="print" />
+		<link href="/site.css" rel="stylesheet" />
+		<style>p { color: red; }</style>
+	</head>
+	<body>
+		<img src="/a.png" />
+		<img src="http://cdn.example.com/b.png" />
+		<img src="/a.png" />
+		<img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=" />
+		<script src="/app.js"></script>
+		<script>var inline = true;</script>
+		<script src="/app.js"></script>
+	</body>
+</html>
+`
+
+// refreshPage returns a page with a refresh meta tag using the given content.
+func refreshPage(content string) string {
+	return fmt.Sprintf(`<!doctype html>
+<html>
+	<head>
+		<title>Surf Refresh</title>
+		<meta http-equiv="Refresh" content="%s">
+	</head>
+	<body></body>
+</html>
+`, content)
+}
+
+var htmlReferer = `<!doctype html>
+<html>
+	<head>
+		<title>Surf Referer</title>
+	</head>
+	<body>
+		<a href="/next">next</a>
+		<form method="post" action="/submit"></form>
+	</body>
+</html>
+`